@@ -0,0 +1,319 @@
+// audit package implements a tamper-evident, append-only audit log.
+// Every record is chained to the previous one by including its digest:
+//
+//	digest = H(prev digest || seq (8 bytes) || time in unix nanoseconds (8 bytes) || checkpoint (1 byte)
+//	           || len(data) (4 bytes) || data)
+//
+// so editing, reordering or deleting a record breaks the digests of all later records.
+// Checkpoint records carry a signature over their digest, which covers the whole chain
+// before them, made for example by the Rsa of signature.NewRsaSigner (signer.Rsa).
+// Records are stored as JSON lines.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/priyanshujain/crypto/hash"
+)
+
+// errors
+var (
+	// ErrRecordTooLarge indicates record data of 4 GiB or more
+	ErrRecordTooLarge = errors.New("audit: record too large")
+
+	// ErrMissingSigner indicates a checkpoint without a signer
+	ErrMissingSigner = errors.New("audit: missing checkpoint signer")
+
+	// ErrClosed indicates an append to a closed log
+	ErrClosed = errors.New("audit: log closed")
+
+	// ErrFailed indicates an append to a log whose earlier write failed
+	ErrFailed = errors.New("audit: log failed")
+
+	// ErrCheckpointFailed indicates a record which was appended, but whose checkpoint was not
+	ErrCheckpointFailed = errors.New("audit: checkpoint failed")
+)
+
+// Signer signs the digest of a checkpoint with the hash type of the log, like the embedded
+// Rsa of a signature.RsaSigner: pass signer.Rsa, as RsaSigner.Sign is the crypto.Signer method
+type Signer interface {
+	Sign(hashed []byte) ([]byte, error)
+}
+
+// Verifier verifies the signature of a checkpoint, like signature.Rsa with the hash type of the log
+type Verifier interface {
+	VerifySignature(hashed, signature []byte) error
+}
+
+// Record is one entry of the log
+type Record struct {
+	Seq        uint64    `json:"seq"` // starts at 1
+	Time       time.Time `json:"time"`
+	Data       []byte    `json:"data,omitempty"`
+	Prev       []byte    `json:"prev"` // digest of the previous record, zeros for the first
+	Digest     []byte    `json:"digest"`
+	Checkpoint bool      `json:"checkpoint,omitempty"`
+	Signature  []byte    `json:"signature,omitempty"` // signature of Digest on checkpoints
+}
+
+// computeDigest returns the digest of the record from its fields
+func (r *Record) computeDigest(htype hash.HashType) ([]byte, error) {
+	if uint64(len(r.Data)) > math.MaxUint32 {
+		return nil, ErrRecordTooLarge
+	}
+	h, err := hash.New(htype)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(r.Prev)
+	var buf [21]byte
+	binary.BigEndian.PutUint64(buf[:8], r.Seq)
+	binary.BigEndian.PutUint64(buf[8:16], uint64(r.Time.UnixNano()))
+	if r.Checkpoint {
+		buf[16] = 1
+	}
+	binary.BigEndian.PutUint32(buf[17:], uint32(len(r.Data)))
+	h.Write(buf[:])
+	h.Write(r.Data)
+	return h.Sum(nil), nil
+}
+
+// Log appends records to a writer, it is safe for concurrent use.
+// After a failed write the record may be partly written, so the Log fails all further
+// appends with ErrFailed. OpenFile verifies what reached the file before continuing.
+type Log struct {
+	mu     sync.Mutex
+	failed error // the write error which failed the log
+	w      io.Writer
+	file   *os.File // set by OpenFile
+	htype  hash.HashType
+	signer Signer
+	every  int // records between automatic checkpoints, zero for none
+	now    func() time.Time
+
+	seq             uint64
+	prev            []byte
+	sinceCheckpoint int
+}
+
+// Option configures a Log created by NewLog or OpenFile
+type Option func(*Log)
+
+// WithHashType sets the hash algorithm of the chain, the default is SHA256
+func WithHashType(htype hash.HashType) Option {
+	return func(l *Log) {
+		l.htype = htype
+	}
+}
+
+// WithCheckpoints signs a checkpoint after every given number of records,
+// the signer must use the hash type of the log
+func WithCheckpoints(signer Signer, every int) Option {
+	return func(l *Log) {
+		l.signer, l.every = signer, every
+	}
+}
+
+// withClock sets the time source, for tests
+func withClock(now func() time.Time) Option {
+	return func(l *Log) {
+		l.now = now
+	}
+}
+
+func newLog(opts []Option) (*Log, error) {
+	l := &Log{htype: hash.SHA256, now: time.Now}
+	for _, opt := range opts {
+		opt(l)
+	}
+	h, err := hash.New(l.htype)
+	if err != nil {
+		return nil, err
+	}
+	if l.every < 0 || (l.every > 0 && l.signer == nil) {
+		return nil, ErrMissingSigner
+	}
+	l.prev = make([]byte, h.Size())
+	return l, nil
+}
+
+// NewLog starts a new chain written to w
+func NewLog(w io.Writer, opts ...Option) (*Log, error) {
+	l, err := newLog(opts)
+	if err != nil {
+		return nil, err
+	}
+	l.w = w
+	return l, nil
+}
+
+// OpenFile opens or creates a log file and continues its chain. The existing records are
+// verified first, without checking signatures, and a broken chain is returned as *ChainError.
+// Every record is synced to disk before Append returns.
+func OpenFile(path string, opts ...Option) (*Log, error) {
+	l, err := newLog(opts)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	result, err := Verify(f, l.htype, nil)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if result.Records > 0 {
+		l.seq, l.prev = result.Records, result.Head
+		l.sinceCheckpoint = int(result.Records - result.LastCheckpoint)
+	}
+	l.w, l.file = f, f
+	return l, nil
+}
+
+// Append adds a record with data, followed by a checkpoint when one is due. When the record
+// is appended but the checkpoint fails, Append returns the record and an error wrapping
+// ErrCheckpointFailed, and the checkpoint is tried again before the next record.
+func (l *Log) Append(data []byte) (*Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.checkpointDue() {
+		if _, err := l.checkpoint(); err != nil {
+			return nil, err
+		}
+	}
+	record, err := l.append(data, false)
+	if err != nil {
+		return nil, err
+	}
+	l.sinceCheckpoint++
+	if l.checkpointDue() {
+		if _, err := l.checkpoint(); err != nil {
+			return record, fmt.Errorf("%w: %w", ErrCheckpointFailed, err)
+		}
+	}
+	return record, nil
+}
+
+func (l *Log) checkpointDue() bool {
+	return l.every > 0 && l.sinceCheckpoint >= l.every
+}
+
+// Checkpoint adds a record signed with the signer of WithCheckpoints
+func (l *Log) Checkpoint() (*Record, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.checkpoint()
+}
+
+func (l *Log) checkpoint() (*Record, error) {
+	if l.signer == nil {
+		return nil, ErrMissingSigner
+	}
+	record, err := l.append(nil, true)
+	if err != nil {
+		return nil, err
+	}
+	l.sinceCheckpoint = 0
+	return record, nil
+}
+
+func (l *Log) append(data []byte, checkpoint bool) (*Record, error) {
+	if l.w == nil {
+		return nil, ErrClosed
+	}
+	if l.failed != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailed, l.failed)
+	}
+	record := &Record{
+		Seq:        l.seq + 1,
+		Time:       l.now().UTC(),
+		Data:       data,
+		Prev:       l.prev,
+		Checkpoint: checkpoint,
+	}
+	var err error
+	if record.Digest, err = record.computeDigest(l.htype); err != nil {
+		return nil, err
+	}
+	if checkpoint {
+		if record.Signature, err = l.signer.Sign(record.Digest); err != nil {
+			return nil, err
+		}
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := l.w.Write(append(line, '\n')); err != nil {
+		l.failed = err
+		return nil, err
+	}
+	if l.file != nil {
+		if err := l.file.Sync(); err != nil {
+			l.failed = err
+			return nil, err
+		}
+	}
+	l.seq, l.prev = record.Seq, record.Digest
+	return record, nil
+}
+
+// Head returns the sequence number and digest of the last record
+func (l *Log) Head() (uint64, []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.seq, bytes.Clone(l.prev)
+}
+
+// Close closes the file of OpenFile, further appends fail
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w = nil
+	if l.file != nil {
+		return l.file.Close()
+	}
+	return nil
+}
+
+// ReadRecords reads all records without verifying them
+func ReadRecords(r io.Reader) ([]Record, error) {
+	var records []Record
+	err := readRecords(r, func(_ int, record *Record) error {
+		records = append(records, *record)
+		return nil
+	})
+	return records, err
+}
+
+func readRecords(r io.Reader, fn func(line int, record *Record) error) error {
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			return &ChainError{Line: n, Err: ErrMalformedRecord}
+		}
+		if err := fn(n, &record); err != nil {
+			return err
+		}
+	}
+}
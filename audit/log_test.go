@@ -0,0 +1,280 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/priyanshujain/crypto/hash"
+	"github.com/priyanshujain/crypto/keystore"
+	"github.com/priyanshujain/crypto/signature"
+)
+
+// signature.Rsa signs and verifies checkpoints
+var (
+	_ Signer   = (*signature.Rsa)(nil)
+	_ Verifier = (*signature.Rsa)(nil)
+)
+
+// testSigner signs checkpoints with RSA PKCS1 v1.5 and SHA256
+var testSigner = func() *signature.Rsa {
+	key, _, err := keystore.GenerateKeyPair(2048)
+	if err != nil {
+		panic(err)
+	}
+	signer, err := signature.NewRsaSigner(key, signature.WithScheme(signature.PKCS1))
+	if err != nil {
+		panic(err)
+	}
+	return signer.Rsa
+}()
+
+func testClock() func() time.Time {
+	t := time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)
+	return func() time.Time {
+		t = t.Add(time.Second)
+		return t
+	}
+}
+
+func writeTestLog(t *testing.T, n int, opts ...Option) []byte {
+	var b bytes.Buffer
+	l, err := NewLog(&b, append([]Option{withClock(testClock())}, opts...)...)
+	if err != nil {
+		t.Fatalf("NewLog() error = %v", err)
+	}
+	for i := 0; i < n; i++ {
+		if _, err := l.Append([]byte("event " + strings.Repeat("x", i))); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	return b.Bytes()
+}
+
+func TestLogAppend(t *testing.T) {
+	var b bytes.Buffer
+	l, _ := NewLog(&b, WithHashType(hash.SHA3_256), withClock(testClock()))
+	first, err := l.Append([]byte("login alice"))
+	if err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	second, _ := l.Append([]byte("logout alice"))
+	if first.Seq != 1 || second.Seq != 2 {
+		t.Errorf("Seq = %d, %d, want 1, 2", first.Seq, second.Seq)
+	}
+	if !bytes.Equal(first.Prev, make([]byte, 32)) || !bytes.Equal(second.Prev, first.Digest) {
+		t.Errorf("records are not chained")
+	}
+	seq, head := l.Head()
+	if seq != 2 || !bytes.Equal(head, second.Digest) {
+		t.Errorf("Head() = %d, %x", seq, head)
+	}
+
+	records, err := ReadRecords(&b)
+	if err != nil {
+		t.Fatalf("ReadRecords() error = %v", err)
+	}
+	if len(records) != 2 || string(records[1].Data) != "logout alice" || !records[1].Time.Equal(second.Time) {
+		t.Errorf("ReadRecords() = %+v", records)
+	}
+
+	l.Close()
+	if _, err := l.Append(nil); err != ErrClosed {
+		t.Errorf("Append() after Close error = %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestLogCheckpoints(t *testing.T) {
+	log := writeTestLog(t, 7, WithCheckpoints(testSigner, 3))
+	records, _ := ReadRecords(bytes.NewReader(log))
+	// 3 records and a checkpoint, twice, and one more record
+	if len(records) != 9 {
+		t.Fatalf("ReadRecords() = %d records, want 9", len(records))
+	}
+	for i, record := range records {
+		want := i == 3 || i == 7
+		if record.Checkpoint != want || (len(record.Signature) > 0) != want {
+			t.Errorf("record %d checkpoint = %v", record.Seq, record.Checkpoint)
+		}
+	}
+	result, err := Verify(bytes.NewReader(log), hash.SHA256, testSigner)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Records != 9 || result.LastCheckpoint != 8 || !bytes.Equal(result.Head, records[8].Digest) {
+		t.Errorf("Verify() = %+v", result)
+	}
+
+	if _, err := NewLog(&bytes.Buffer{}, WithCheckpoints(nil, 3)); err != ErrMissingSigner {
+		t.Errorf("NewLog() without signer error = %v, want %v", err, ErrMissingSigner)
+	}
+	l, _ := NewLog(&bytes.Buffer{})
+	if _, err := l.Checkpoint(); err != ErrMissingSigner {
+		t.Errorf("Checkpoint() without signer error = %v, want %v", err, ErrMissingSigner)
+	}
+	if _, err := NewLog(&bytes.Buffer{}, WithHashType(0)); err != hash.ErrInvalidHashType {
+		t.Errorf("NewLog() error = %v, want %v", err, hash.ErrInvalidHashType)
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := OpenFile(path, WithCheckpoints(testSigner, 2), withClock(testClock()))
+	if err != nil {
+		t.Fatalf("OpenFile() error = %v", err)
+	}
+	l.Append([]byte("one"))
+	l.Append([]byte("two"))
+	l.Append([]byte("three"))
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// the chain continues after reopening
+	l, err = OpenFile(path, WithCheckpoints(testSigner, 2), withClock(testClock()))
+	if err != nil {
+		t.Fatalf("OpenFile() of an existing log error = %v", err)
+	}
+	if seq, _ := l.Head(); seq != 4 {
+		t.Errorf("Head() after reopening = %d, want 4", seq)
+	}
+	record, _ := l.Append([]byte("four"))
+	if record.Seq != 5 {
+		t.Errorf("Append() after reopening Seq = %d, want 5", record.Seq)
+	}
+	l.Close()
+
+	result, err := VerifyFile(path, hash.SHA256, testSigner)
+	if err != nil {
+		t.Fatalf("VerifyFile() error = %v", err)
+	}
+	// the checkpoint after "four" completes the second pair
+	if result.Records != 6 || result.LastCheckpoint != 6 {
+		t.Errorf("VerifyFile() = %+v", result)
+	}
+
+	// a tampered file is not continued
+	content, _ := os.ReadFile(path)
+	os.WriteFile(path, bytes.Replace(content, []byte(`"seq":2`), []byte(`"seq":3`), 1), 0o600)
+	var chainErr *ChainError
+	if _, err := OpenFile(path); !errors.As(err, &chainErr) || chainErr.Line != 2 {
+		t.Errorf("OpenFile() of a tampered log error = %v", err)
+	}
+}
+
+// shortWriter writes the first limit bytes and fails
+type shortWriter struct {
+	bytes.Buffer
+	limit int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if w.Len()+len(p) > w.limit {
+		n, _ := w.Buffer.Write(p[:w.limit-w.Len()])
+		return n, io.ErrShortWrite
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestLogWriteError(t *testing.T) {
+	w := &shortWriter{limit: 300}
+	l, _ := NewLog(w, withClock(testClock()))
+	if _, err := l.Append([]byte("one")); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if _, err := l.Append([]byte(strings.Repeat("x", 200))); err != io.ErrShortWrite {
+		t.Fatalf("Append() error = %v, want %v", err, io.ErrShortWrite)
+	}
+	// the partly written record is not followed by more records
+	w.limit = 1 << 20
+	written := w.Len()
+	if _, err := l.Append([]byte("three")); !errors.Is(err, ErrFailed) || !errors.Is(err, io.ErrShortWrite) {
+		t.Errorf("Append() after a failed write error = %v, want %v", err, ErrFailed)
+	}
+	if w.Len() != written {
+		t.Errorf("failed log wrote %d more bytes", w.Len()-written)
+	}
+}
+
+// failingSigner fails the next fail signatures
+type failingSigner struct {
+	fail int
+}
+
+var errSign = errors.New("sign failed")
+
+func (s *failingSigner) Sign(hashed []byte) ([]byte, error) {
+	if s.fail > 0 {
+		s.fail--
+		return nil, errSign
+	}
+	return testSigner.Sign(hashed)
+}
+
+func TestLogCheckpointError(t *testing.T) {
+	var b bytes.Buffer
+	signer := &failingSigner{fail: 1}
+	l, _ := NewLog(&b, WithCheckpoints(signer, 2), withClock(testClock()))
+	l.Append([]byte("one"))
+	record, err := l.Append([]byte("two"))
+	if !errors.Is(err, ErrCheckpointFailed) || !errors.Is(err, errSign) {
+		t.Errorf("Append() error = %v, want %v", err, ErrCheckpointFailed)
+	}
+	if record == nil || record.Seq != 2 {
+		t.Fatalf("Append() = %+v, want the appended record", record)
+	}
+	// the missed checkpoint is written before the next record
+	record, err = l.Append([]byte("three"))
+	if err != nil || record.Seq != 4 {
+		t.Errorf("Append() = %+v, %v, want seq 4", record, err)
+	}
+	result, err := Verify(bytes.NewReader(b.Bytes()), hash.SHA256, testSigner, WithCheckpointInterval(2))
+	if err != nil || result.LastCheckpoint != 3 {
+		t.Errorf("Verify() = %+v, %v", result, err)
+	}
+}
+
+func TestLogConcurrentAppend(t *testing.T) {
+	var b bytes.Buffer
+	l, _ := NewLog(&b, WithCheckpoints(testSigner, 10))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				if _, err := l.Append([]byte("event")); err != nil {
+					t.Errorf("Append() error = %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	result, err := Verify(bytes.NewReader(b.Bytes()), hash.SHA256, testSigner, WithCheckpointInterval(10))
+	if err != nil || result.Records != 110 || result.Unauthenticated != 0 {
+		t.Errorf("Verify() = %+v, %v", result, err)
+	}
+}
+
+func TestRecordJSON(t *testing.T) {
+	log := writeTestLog(t, 1)
+	var fields map[string]any
+	if err := json.Unmarshal(bytes.TrimSpace(log), &fields); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	for _, name := range []string{"seq", "time", "data", "prev", "digest"} {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("record has no %q field: %s", name, log)
+		}
+	}
+	if _, ok := fields["signature"]; ok {
+		t.Errorf("record without checkpoint has a signature: %s", log)
+	}
+}
@@ -0,0 +1,140 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/priyanshujain/crypto/hash"
+)
+
+// reasons of a ChainError
+var (
+	// ErrMalformedRecord indicates a line which is not a JSON record
+	ErrMalformedRecord = errors.New("audit: malformed record")
+
+	// ErrSequenceGap indicates a record whose sequence number does not follow the previous one
+	ErrSequenceGap = errors.New("audit: record out of sequence")
+
+	// ErrBrokenLink indicates a record which does not point to the digest of the previous one
+	ErrBrokenLink = errors.New("audit: record not linked to the previous record")
+
+	// ErrDigestMismatch indicates a record whose content does not match its digest
+	ErrDigestMismatch = errors.New("audit: record digest mismatch")
+
+	// ErrInvalidSignature indicates a checkpoint whose signature does not verify
+	ErrInvalidSignature = errors.New("audit: invalid checkpoint signature")
+
+	// ErrCheckpointGap indicates more records after a checkpoint than the checkpoint interval
+	ErrCheckpointGap = errors.New("audit: checkpoint missing")
+)
+
+// ErrNoCheckpoint indicates records without any checkpoint, when verifying signatures
+var ErrNoCheckpoint = errors.New("audit: log has no signed checkpoint")
+
+// ChainError reports the first record which breaks the chain
+type ChainError struct {
+	Line int    // line of the record, starting at 1
+	Seq  uint64 // sequence number of the record as stored, zero if malformed
+	Err  error
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("%v at line %d (seq %d)", e.Err, e.Line, e.Seq)
+}
+
+func (e *ChainError) Unwrap() error {
+	return e.Err
+}
+
+// VerifyResult describes a verified log
+type VerifyResult struct {
+	Records        uint64 // number of records
+	Head           []byte // digest of the last record
+	LastCheckpoint uint64 // sequence number of the last checkpoint, zero if none
+
+	// Unauthenticated is the number of records after LastCheckpoint, no checkpoint
+	// signature covers them, so they can be changed without detection
+	Unauthenticated uint64
+}
+
+// VerifyOption configures Verify and VerifyFile
+type VerifyOption func(*verifyOptions)
+
+type verifyOptions struct {
+	every uint64
+}
+
+// WithCheckpointInterval fails verification with ErrCheckpointGap when more than every records
+// follow a checkpoint, or the start of the log, without the next checkpoint. It is the interval
+// of WithCheckpoints the log was written with.
+func WithCheckpointInterval(every int) VerifyOption {
+	return func(o *verifyOptions) {
+		o.every = uint64(max(every, 0))
+	}
+}
+
+// Verify checks the chain of records read from r and, with a verifier, the checkpoint
+// signatures. The first broken record is returned as *ChainError. With a verifier a log
+// without any checkpoint fails with ErrNoCheckpoint, since nothing in it is signed.
+// Records removed from the end of the log are only detected by comparing with a checkpoint
+// kept elsewhere, and the records after the last checkpoint are reported as Unauthenticated.
+func Verify(r io.Reader, htype hash.HashType, verifier Verifier, opts ...VerifyOption) (*VerifyResult, error) {
+	var o verifyOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	h, err := hash.New(htype)
+	if err != nil {
+		return nil, err
+	}
+	result := &VerifyResult{Head: make([]byte, h.Size())}
+	err = readRecords(r, func(line int, record *Record) error {
+		fail := func(err error) error {
+			return &ChainError{Line: line, Seq: record.Seq, Err: err}
+		}
+		if record.Seq != result.Records+1 {
+			return fail(ErrSequenceGap)
+		}
+		if !bytes.Equal(record.Prev, result.Head) {
+			return fail(ErrBrokenLink)
+		}
+		digest, err := record.computeDigest(htype)
+		if err != nil {
+			return fail(err)
+		}
+		if !bytes.Equal(digest, record.Digest) {
+			return fail(ErrDigestMismatch)
+		}
+		if record.Checkpoint {
+			if verifier != nil && verifier.VerifySignature(record.Digest, record.Signature) != nil {
+				return fail(ErrInvalidSignature)
+			}
+			result.LastCheckpoint = record.Seq
+		} else if o.every > 0 && record.Seq-result.LastCheckpoint > o.every {
+			return fail(ErrCheckpointGap)
+		}
+		result.Records, result.Head = record.Seq, record.Digest
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if verifier != nil && result.Records > 0 && result.LastCheckpoint == 0 {
+		return nil, ErrNoCheckpoint
+	}
+	result.Unauthenticated = result.Records - result.LastCheckpoint
+	return result, nil
+}
+
+// VerifyFile verifies the log file at path
+func VerifyFile(path string, htype hash.HashType, verifier Verifier, opts ...VerifyOption) (*VerifyResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Verify(f, htype, verifier, opts...)
+}
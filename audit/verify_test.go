@@ -0,0 +1,208 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/priyanshujain/crypto/hash"
+)
+
+// tamper decodes the records, changes them and encodes them again
+func tamper(t *testing.T, log []byte, fn func([]Record) []Record) []byte {
+	records, err := ReadRecords(bytes.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	for _, record := range fn(records) {
+		line, _ := json.Marshal(record)
+		b.Write(append(line, '\n'))
+	}
+	return b.Bytes()
+}
+
+func TestVerifyTampering(t *testing.T) {
+	log := writeTestLog(t, 6, WithCheckpoints(testSigner, 4))
+
+	tests := []struct {
+		name string
+		log  []byte
+		line int
+		err  error
+	}{
+		{
+			name: "edited data",
+			log: tamper(t, log, func(records []Record) []Record {
+				records[2].Data = []byte("forged")
+				return records
+			}),
+			line: 3,
+			err:  ErrDigestMismatch,
+		},
+		{
+			name: "edited data with new digest",
+			log: tamper(t, log, func(records []Record) []Record {
+				records[2].Data = []byte("forged")
+				records[2].Digest, _ = records[2].computeDigest(hash.SHA256)
+				return records
+			}),
+			line: 4,
+			err:  ErrBrokenLink,
+		},
+		{
+			name: "edited time",
+			log: tamper(t, log, func(records []Record) []Record {
+				records[1].Time = records[1].Time.Add(-1)
+				return records
+			}),
+			line: 2,
+			err:  ErrDigestMismatch,
+		},
+		{
+			name: "deleted record",
+			log: tamper(t, log, func(records []Record) []Record {
+				return append(records[:1], records[2:]...)
+			}),
+			line: 2,
+			err:  ErrSequenceGap,
+		},
+		{
+			name: "swapped records",
+			log: tamper(t, log, func(records []Record) []Record {
+				records[1], records[2] = records[2], records[1]
+				return records
+			}),
+			line: 2,
+			err:  ErrSequenceGap,
+		},
+		{
+			name: "rewritten chain",
+			log: tamper(t, log, func(records []Record) []Record {
+				// recomputing every digest still fails at the signed checkpoint
+				records[0].Data = []byte("forged")
+				for i := range records {
+					if i > 0 {
+						records[i].Prev = records[i-1].Digest
+					}
+					records[i].Digest, _ = records[i].computeDigest(hash.SHA256)
+				}
+				return records
+			}),
+			line: 5,
+			err:  ErrInvalidSignature,
+		},
+		{
+			name: "removed checkpoint signature",
+			log: tamper(t, log, func(records []Record) []Record {
+				records[4].Signature = nil
+				return records
+			}),
+			line: 5,
+			err:  ErrInvalidSignature,
+		},
+		{
+			name: "downgraded checkpoint",
+			log: tamper(t, log, func(records []Record) []Record {
+				records[4].Checkpoint = false
+				return records
+			}),
+			line: 5,
+			err:  ErrDigestMismatch,
+		},
+		{
+			name: "malformed line",
+			log:  append(append([]byte{}, log...), []byte("{not json\n")...),
+			line: 8,
+			err:  ErrMalformedRecord,
+		},
+	}
+	for _, tt := range tests {
+		_, err := Verify(bytes.NewReader(tt.log), hash.SHA256, testSigner)
+		var chainErr *ChainError
+		if !errors.As(err, &chainErr) {
+			t.Errorf("%s: Verify() error = %v, want *ChainError", tt.name, err)
+			continue
+		}
+		if chainErr.Line != tt.line || !errors.Is(err, tt.err) {
+			t.Errorf("%s: Verify() error = %v, want %v at line %d", tt.name, err, tt.err, tt.line)
+		}
+	}
+}
+
+// rechain renumbers the records and recomputes their links and digests
+func rechain(records []Record) []Record {
+	for i := range records {
+		records[i].Seq = uint64(i + 1)
+		if i > 0 {
+			records[i].Prev = records[i-1].Digest
+		}
+		records[i].Digest, _ = records[i].computeDigest(hash.SHA256)
+	}
+	return records
+}
+
+func TestVerifyCheckpoints(t *testing.T) {
+	log := writeTestLog(t, 7, WithCheckpoints(testSigner, 3))
+	result, err := Verify(bytes.NewReader(log), hash.SHA256, testSigner, WithCheckpointInterval(3))
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if result.Records != 9 || result.LastCheckpoint != 8 || result.Unauthenticated != 1 {
+		t.Errorf("Verify() = %+v", result)
+	}
+
+	// a chain rebuilt without its checkpoints is consistent, but nothing in it is signed
+	stripped := tamper(t, log, func(records []Record) []Record {
+		var kept []Record
+		for _, record := range records {
+			if !record.Checkpoint {
+				kept = append(kept, record)
+			}
+		}
+		return rechain(kept)
+	})
+	if _, err := Verify(bytes.NewReader(stripped), hash.SHA256, testSigner); err != ErrNoCheckpoint {
+		t.Errorf("Verify() without checkpoints error = %v, want %v", err, ErrNoCheckpoint)
+	}
+	if result, err := Verify(bytes.NewReader(stripped), hash.SHA256, nil); err != nil || result.Unauthenticated != 7 {
+		t.Errorf("Verify() without verifier = %+v, %v", result, err)
+	}
+
+	// dropping the last checkpoint leaves 4 records after the first one
+	dropped := tamper(t, log, func(records []Record) []Record {
+		return rechain(append(records[:7], records[8:]...))
+	})
+	if _, err := Verify(bytes.NewReader(dropped), hash.SHA256, testSigner); err != nil {
+		t.Errorf("Verify() without interval error = %v", err)
+	}
+	_, err = Verify(bytes.NewReader(dropped), hash.SHA256, testSigner, WithCheckpointInterval(3))
+	var chainErr *ChainError
+	if !errors.As(err, &chainErr) || chainErr.Line != 8 || !errors.Is(err, ErrCheckpointGap) {
+		t.Errorf("Verify() error = %v, want %v at line 8", err, ErrCheckpointGap)
+	}
+}
+
+func TestVerifyWithoutVerifier(t *testing.T) {
+	log := writeTestLog(t, 4, WithCheckpoints(testSigner, 2))
+	forged := tamper(t, log, func(records []Record) []Record {
+		records[2].Signature = []byte("forged")
+		return records
+	})
+	// signatures are only checked with a verifier
+	if _, err := Verify(bytes.NewReader(forged), hash.SHA256, nil); err != nil {
+		t.Errorf("Verify() without verifier error = %v", err)
+	}
+	if _, err := Verify(bytes.NewReader(forged), hash.SHA256, testSigner); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrInvalidSignature)
+	}
+	// the hash type is part of the chain
+	if _, err := Verify(bytes.NewReader(log), hash.SHA512, nil); !errors.Is(err, ErrBrokenLink) {
+		t.Errorf("Verify() with another hash type error = %v, want %v", err, ErrBrokenLink)
+	}
+	result, err := Verify(bytes.NewReader(nil), hash.SHA256, nil)
+	if err != nil || result.Records != 0 {
+		t.Errorf("Verify() of an empty log = %+v, %v", result, err)
+	}
+}
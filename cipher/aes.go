@@ -18,6 +18,7 @@ const (
 	CFB AesBlockMode = 1 + iota // https://en.wikipedia.org/wiki/Block_cipher_mode_of_operation#Cipher_feedback_(CFB)
 	CTR                         // https://en.wikipedia.org/wiki/Block_cipher_mode_of_operation#Counter_(CTR)
 	CBC                         // https://en.wikipedia.org/wiki/Block_cipher_mode_of_operation#Cipher_block_chaining_(CBC)
+	GCM                         // https://en.wikipedia.org/wiki/Galois/Counter_Mode
 )
 
 // supported padding schemes
@@ -33,6 +34,7 @@ type Aes struct {
 	iv      []byte
 	mode    AesBlockMode
 	padding AesPaddingScheme
+	nonce   NonceGenerator // GCM nonces, iv is not used in GCM mode
 }
 
 // Errors
@@ -44,6 +46,18 @@ var (
 	ErrUnsupportedMode = errors.New("unsupported aes mode")
 )
 
+// NewAesGCM returns an Aes in GCM mode which takes a fresh nonce from nonce for every
+// encryption. The key must be 16, 24 or 32 bytes long.
+func NewAesGCM(key []byte, nonce NonceGenerator) (*Aes, error) {
+	if _, err := aes.NewCipher(key); err != nil {
+		return nil, err
+	}
+	if nonce == nil {
+		return nil, ErrNoNonceGenerator
+	}
+	return &Aes{key: key, mode: GCM, nonce: nonce}, nil
+}
+
 func (x *Aes) cfbEncrypt(block cipher.Block, src, dst []byte) error {
 	stream := cipher.NewCFBEncrypter(block, x.iv)
 	stream.XORKeyStream(dst, src)
@@ -80,12 +94,43 @@ func (x *Aes) ctrDecrypt(block cipher.Block, src, dst []byte) error {
 	return nil
 }
 
+// gcmEncrypt seals src with a fresh nonce and returns nonce || ciphertext || tag
+func (x *Aes) gcmEncrypt(block cipher.Block, src []byte) ([]byte, error) {
+	if x.nonce == nil {
+		return nil, ErrNoNonceGenerator
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := x.nonce.Nonce()
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, src, nil), nil
+}
+
+func (x *Aes) gcmDecrypt(block cipher.Block, src []byte) ([]byte, error) {
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(src) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrShortBlock
+	}
+	nonce, src := src[:aead.NonceSize()], src[aead.NonceSize():]
+	return aead.Open(nil, nonce, src, nil)
+}
+
 // encrypts bytes array into bytes array
 func (x *Aes) Encrypt(src []byte) ([]byte, error) {
 	block, err := aes.NewCipher(x.key)
 	if err != nil {
 		return nil, err
 	}
+	if x.mode == GCM {
+		return x.gcmEncrypt(block, src)
+	}
 	if x.padding != 0 {
 		src, err = AddPadding(src, x.padding)
 		if err != nil {
@@ -112,6 +157,9 @@ func (x *Aes) Decrypt(src []byte) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	if x.mode == GCM {
+		return x.gcmDecrypt(block, src)
+	}
 	if len(src) < aes.BlockSize {
 		return nil, ErrShortBlock
 	}
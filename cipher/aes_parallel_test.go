@@ -0,0 +1,108 @@
+package cipher
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"testing"
+)
+
+var aesParallelTestCases = []struct {
+	name    string
+	iv      []byte
+	size    int
+	padding AesPaddingScheme
+}{
+	{
+		name: "single-segment",
+		iv:   []byte{51, 49, 52, 50, 49, 52, 52, 49, 52, 56, 55, 50, 53, 49, 48, 57},
+		size: 1000,
+	},
+	{
+		name: "unaligned-tail",
+		iv:   []byte{51, 49, 52, 50, 49, 52, 52, 49, 52, 56, 55, 50, 53, 49, 48, 57},
+		size: 5*parallelSegmentSize + 13,
+	},
+	{
+		name:    "padded",
+		iv:      []byte{51, 49, 52, 50, 49, 52, 52, 49, 52, 56, 55, 50, 53, 49, 48, 57},
+		size:    3 * parallelSegmentSize,
+		padding: PKCS7,
+	},
+	{
+		name: "counter-wrap",
+		iv:   bytes.Repeat([]byte{0xff}, 16),
+		size: 4*parallelSegmentSize + 1,
+	},
+	{
+		name: "carry",
+		iv:   []byte{0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xf0},
+		size: 4 * parallelSegmentSize,
+	},
+}
+
+func TestAesEncryptParallel(t *testing.T) {
+	key := []byte{160, 153, 156, 74, 55, 224, 78, 74, 56, 176, 207, 163, 173, 44, 109, 211}
+	for _, test := range aesParallelTestCases {
+		t.Run(fmt.Sprintf("Test: %s", test.name), func(t *testing.T) {
+			data := make([]byte, test.size)
+			rand.Read(data)
+			aes := Aes{key: key, iv: test.iv, mode: CTR, padding: test.padding}
+
+			want, err := aes.Encrypt(data)
+			if err != nil {
+				t.Fatalf("Encrypt() error = %v", err)
+			}
+			got, err := aes.EncryptParallel(data, 4)
+			if err != nil {
+				t.Fatalf("EncryptParallel() error = %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("EncryptParallel() output differs from Encrypt()")
+			}
+			plaintext, err := aes.DecryptParallel(got, 0)
+			if err != nil {
+				t.Fatalf("DecryptParallel() error = %v", err)
+			}
+			if !bytes.Equal(plaintext, data) {
+				t.Errorf("DecryptParallel() output differs from plaintext")
+			}
+		})
+	}
+}
+
+func TestAesEncryptParallelUnsupportedMode(t *testing.T) {
+	aes := Aes{key: make([]byte, 16), iv: make([]byte, 16), mode: CBC, padding: PKCS7}
+	if _, err := aes.EncryptParallel([]byte("test"), 2); err != ErrUnsupportedMode {
+		t.Errorf("EncryptParallel() error = %v, want %v", err, ErrUnsupportedMode)
+	}
+}
+
+// go test -run XXX -bench AesCTR -benchtime 3x ./cipher
+func BenchmarkAesCTR(b *testing.B) {
+	key := make([]byte, 32)
+	iv := make([]byte, 16)
+	for _, size := range []int{64 << 20, 1 << 30, 2 << 30} {
+		if testing.Short() && size > 64<<20 {
+			continue
+		}
+		data := make([]byte, size)
+		aes := Aes{key: key, iv: iv, mode: CTR}
+		b.Run(fmt.Sprintf("sequential-%dMB", size>>20), func(b *testing.B) {
+			b.SetBytes(int64(size))
+			for i := 0; i < b.N; i++ {
+				if _, err := aes.Encrypt(data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("parallel-%dMB", size>>20), func(b *testing.B) {
+			b.SetBytes(int64(size))
+			for i := 0; i < b.N; i++ {
+				if _, err := aes.EncryptParallel(data, 0); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
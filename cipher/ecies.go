@@ -0,0 +1,154 @@
+// It implements ECIES hybrid public key encryption.
+// A fresh ephemeral key pair is agreed with the recipient key using ECDH, the shared
+// secret is expanded with HKDF into an AES-256-GCM key and nonce, and the ciphertext
+// carries everything the recipient needs:
+//
+//	curve (1 byte) || ephemeral public key || AES-GCM ciphertext || tag
+//
+// HKDF uses SHA-256 for P-256 and X25519 and SHA-384 for P-384, its info binds both
+// public keys.
+package cipher
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"hash"
+
+	"github.com/priyanshujain/crypto/keystore"
+)
+
+// label prefixed to the HKDF info
+const eciesInfo = "github.com/priyanshujain/crypto ECIES"
+
+// errors
+var (
+	// ErrMissingEcKey indicates an Ecies without the key required by the operation
+	ErrMissingEcKey = errors.New("missing elliptic curve key")
+
+	// ErrCurveMismatch indicates a ciphertext for a different curve than the private key
+	ErrCurveMismatch = errors.New("ciphertext curve does not match key")
+)
+
+type Ecies struct {
+	privateKey *keystore.EcPrivateKey
+	publicKey  *keystore.EcPublicKey
+}
+
+var _ BlockMode = (*Ecies)(nil)
+
+// NewEciesEncryptor returns an Ecies which encrypts to pub,
+// pub must be on P-256, P-384 or X25519
+func NewEciesEncryptor(pub *keystore.EcPublicKey) (*Ecies, error) {
+	if pub == nil || pub.Key == nil {
+		return nil, ErrMissingEcKey
+	}
+	if _, err := keystore.EcdhCurve(pub.Curve()); err != nil {
+		return nil, err
+	}
+	return &Ecies{publicKey: pub}, nil
+}
+
+// NewEciesDecryptor returns an Ecies which decrypts with priv,
+// priv must be on P-256, P-384 or X25519
+func NewEciesDecryptor(priv *keystore.EcPrivateKey) (*Ecies, error) {
+	if priv == nil || priv.Key == nil {
+		return nil, ErrMissingEcKey
+	}
+	if _, err := keystore.EcdhCurve(priv.Curve()); err != nil {
+		return nil, err
+	}
+	return &Ecies{privateKey: priv}, nil
+}
+
+func eciesKdfHash(curve keystore.EcCurve) func() hash.Hash {
+	if curve == keystore.P384 {
+		return sha512.New384
+	}
+	return sha256.New
+}
+
+// eciesAead derives the AES-256-GCM key and nonce for one message
+func eciesAead(curve keystore.EcCurve, secret, ephemeral, recipient []byte) (cipher.AEAD, []byte, error) {
+	info := make([]byte, 0, len(eciesInfo)+len(ephemeral)+len(recipient))
+	info = append(info, eciesInfo...)
+	info = append(info, ephemeral...)
+	info = append(info, recipient...)
+	okm, err := hkdf.Key(eciesKdfHash(curve), secret, nil, string(info), 32+GCMNonceSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, err := aes.NewCipher(okm[:32])
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, okm[32:], nil
+}
+
+// encrypts bytes array to the public key
+func (x *Ecies) Encrypt(src []byte) ([]byte, error) {
+	if x.publicKey == nil {
+		return nil, ErrMissingEcKey
+	}
+	curve := x.publicKey.Curve()
+	ephemeral, err := x.publicKey.Key.Curve().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := ephemeral.ECDH(x.publicKey.Key)
+	if err != nil {
+		return nil, err
+	}
+	ephemeralBytes := ephemeral.PublicKey().Bytes()
+	aead, nonce, err := eciesAead(curve, secret, ephemeralBytes, x.publicKey.Key.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	dst := make([]byte, 0, 1+len(ephemeralBytes)+len(src)+aead.Overhead())
+	dst = append(dst, byte(curve))
+	dst = append(dst, ephemeralBytes...)
+	return aead.Seal(dst, nonce, src, nil), nil
+}
+
+// decrypts bytes array with the private key
+func (x *Ecies) Decrypt(src []byte) ([]byte, error) {
+	if x.privateKey == nil {
+		return nil, ErrMissingEcKey
+	}
+	curve := x.privateKey.Curve()
+	if len(src) == 0 {
+		return nil, ErrShortBlock
+	}
+	if keystore.EcCurve(src[0]) != curve {
+		return nil, ErrCurveMismatch
+	}
+	recipient := x.privateKey.Key.PublicKey().Bytes()
+	// the ephemeral key is encoded the same way and has the same size as the recipient key
+	size := len(recipient)
+	if len(src) < 1+size+aes.BlockSize {
+		return nil, ErrShortBlock
+	}
+	ephemeralBytes, src := src[1:1+size], src[1+size:]
+	ephemeral, err := x.privateKey.Key.Curve().NewPublicKey(ephemeralBytes)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := x.privateKey.Key.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+	aead, nonce, err := eciesAead(curve, secret, ephemeralBytes, recipient)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, nonce, src, nil)
+}
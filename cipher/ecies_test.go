@@ -0,0 +1,75 @@
+package cipher
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/priyanshujain/crypto/keystore"
+)
+
+func TestEcies(t *testing.T) {
+	plaintext := []byte("{\"requestId\":\"23\",\"actionName\":\"SELLER_SETTLEMENT_STATUS\"}")
+	for _, curve := range []keystore.EcCurve{keystore.P256, keystore.P384, keystore.X25519} {
+		t.Run(fmt.Sprintf("ECIES: curve %d", curve), func(t *testing.T) {
+			privateKey, publicKey, err := keystore.GenerateEcKeyPair(curve)
+			if err != nil {
+				t.Fatalf("GenerateEcKeyPair() error = %v", err)
+			}
+			encryptor, _ := NewEciesEncryptor(publicKey)
+			decryptor, _ := NewEciesDecryptor(privateKey)
+
+			ciphertext, err := encryptor.Encrypt(plaintext)
+			if err != nil {
+				t.Fatalf("Encrypt() error = %v", err)
+			}
+			if keystore.EcCurve(ciphertext[0]) != curve {
+				t.Errorf("ciphertext curve = %d, want %d", ciphertext[0], curve)
+			}
+			result, err := decryptor.Decrypt(ciphertext)
+			if err != nil {
+				t.Fatalf("Decrypt() error = %v", err)
+			}
+			if !bytes.Equal(result, plaintext) {
+				t.Errorf("got %q, wanted %q", result, plaintext)
+			}
+
+			ciphertext[len(ciphertext)-1] ^= 1
+			if _, err := decryptor.Decrypt(ciphertext); err == nil {
+				t.Errorf("Decrypt() of a tampered ciphertext succeeded")
+			}
+			if _, err := encryptor.Decrypt(ciphertext); err != ErrMissingEcKey {
+				t.Errorf("Decrypt() error = %v, want %v", err, ErrMissingEcKey)
+			}
+		})
+	}
+}
+
+func TestEciesCurveMismatch(t *testing.T) {
+	_, publicKey, _ := keystore.GenerateEcKeyPair(keystore.P256)
+	privateKey, _, _ := keystore.GenerateEcKeyPair(keystore.P384)
+	encryptor, _ := NewEciesEncryptor(publicKey)
+	decryptor, _ := NewEciesDecryptor(privateKey)
+	ciphertext, _ := encryptor.Encrypt([]byte("test"))
+	if _, err := decryptor.Decrypt(ciphertext); err != ErrCurveMismatch {
+		t.Errorf("Decrypt() error = %v, want %v", err, ErrCurveMismatch)
+	}
+	if _, err := decryptor.Decrypt(ciphertext[:1]); err != ErrCurveMismatch {
+		t.Errorf("Decrypt() error = %v, want %v", err, ErrCurveMismatch)
+	}
+}
+
+func TestEciesUnsupportedCurve(t *testing.T) {
+	key, err := ecdh.P521().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewEciesEncryptor(&keystore.EcPublicKey{Key: key.PublicKey()}); err != keystore.ErrUnsupportedCurve {
+		t.Errorf("NewEciesEncryptor() error = %v, want %v", err, keystore.ErrUnsupportedCurve)
+	}
+	if _, err := NewEciesDecryptor(&keystore.EcPrivateKey{Key: key}); err != keystore.ErrUnsupportedCurve {
+		t.Errorf("NewEciesDecryptor() error = %v, want %v", err, keystore.ErrUnsupportedCurve)
+	}
+}
@@ -0,0 +1,519 @@
+// It implements Hybrid Public Key Encryption (HPKE)
+// https://datatracker.ietf.org/doc/html/rfc9180
+// with DHKEM(P-256) and DHKEM(X25519), HKDF-SHA256/SHA512 and AES-128/256-GCM
+// in the Base, PSK, Auth and AuthPSK modes.
+package cipher
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"math"
+	"sync"
+
+	"github.com/priyanshujain/crypto/keystore"
+)
+
+type HpkeKem uint16
+
+// Key encapsulation mechanisms (RFC 9180 section 7.1)
+const (
+	HpkeDhkemP256   HpkeKem = 0x0010 // DHKEM(P-256, HKDF-SHA256)
+	HpkeDhkemX25519 HpkeKem = 0x0020 // DHKEM(X25519, HKDF-SHA256)
+)
+
+type HpkeKdf uint16
+
+// Key derivation functions (RFC 9180 section 7.2)
+const (
+	HpkeHkdfSha256 HpkeKdf = 0x0001
+	HpkeHkdfSha512 HpkeKdf = 0x0003
+)
+
+type HpkeAead uint16
+
+// Authenticated encryption algorithms (RFC 9180 section 7.3)
+const (
+	HpkeAes128Gcm HpkeAead = 0x0001
+	HpkeAes256Gcm HpkeAead = 0x0002
+)
+
+type HpkeMode uint8
+
+// HPKE modes (RFC 9180 section 5)
+const (
+	HpkeModeBase    HpkeMode = 0x00
+	HpkeModePsk     HpkeMode = 0x01
+	HpkeModeAuth    HpkeMode = 0x02
+	HpkeModeAuthPsk HpkeMode = 0x03
+)
+
+// errors
+var (
+	// ErrInvalidHpkeSuite indicates an unsupported KEM, KDF or AEAD identifier
+	ErrInvalidHpkeSuite = errors.New("invalid hpke cipher suite")
+
+	// ErrInvalidPsk indicates that only one of psk and psk id is set
+	ErrInvalidPsk = errors.New("psk and psk id must be set together")
+
+	// ErrInvalidHpkeContext indicates Seal on a receiver context or Open on a sender context
+	ErrInvalidHpkeContext = errors.New("invalid hpke context role")
+
+	// ErrInvalidExportLength indicates an exporter length above 255 * hash size
+	ErrInvalidExportLength = errors.New("invalid hpke export length")
+)
+
+// Hpke is an HPKE cipher suite
+type Hpke struct {
+	kem  HpkeKem
+	kdf  HpkeKdf
+	aead HpkeAead
+}
+
+// HpkeOption selects the PSK and Auth modes
+type HpkeOption func(*hpkeParams)
+
+type hpkeParams struct {
+	psk       []byte
+	pskID     []byte
+	senderKey *keystore.EcPrivateKey
+	senderPub *keystore.EcPublicKey
+}
+
+// WithHpkePsk authenticates both parties with a pre-shared key (PSK and AuthPSK modes)
+func WithHpkePsk(psk, pskID []byte) HpkeOption {
+	return func(p *hpkeParams) {
+		p.psk = psk
+		p.pskID = pskID
+	}
+}
+
+// WithHpkeSenderKey authenticates the sender with its private key (Auth and AuthPSK modes)
+func WithHpkeSenderKey(skS *keystore.EcPrivateKey) HpkeOption {
+	return func(p *hpkeParams) {
+		p.senderKey = skS
+	}
+}
+
+// WithHpkeSenderPublicKey makes the receiver check the sender public key (Auth and AuthPSK modes)
+func WithHpkeSenderPublicKey(pkS *keystore.EcPublicKey) HpkeOption {
+	return func(p *hpkeParams) {
+		p.senderPub = pkS
+	}
+}
+
+func (p *hpkeParams) mode() (HpkeMode, error) {
+	if (len(p.psk) == 0) != (len(p.pskID) == 0) {
+		return 0, ErrInvalidPsk
+	}
+	auth := p.senderKey != nil || p.senderPub != nil
+	switch {
+	case len(p.psk) > 0 && auth:
+		return HpkeModeAuthPsk, nil
+	case len(p.psk) > 0:
+		return HpkeModePsk, nil
+	case auth:
+		return HpkeModeAuth, nil
+	default:
+		return HpkeModeBase, nil
+	}
+}
+
+// NewHpke returns the cipher suite made of kem, kdf and aead
+func NewHpke(kem HpkeKem, kdf HpkeKdf, aead HpkeAead) (*Hpke, error) {
+	x := &Hpke{kem: kem, kdf: kdf, aead: aead}
+	if _, err := x.curve(); err != nil {
+		return nil, err
+	}
+	if x.kdfHash() == nil {
+		return nil, ErrInvalidHpkeSuite
+	}
+	if x.keySize() == 0 {
+		return nil, ErrInvalidHpkeSuite
+	}
+	return x, nil
+}
+
+func (x *Hpke) curve() (ecdh.Curve, error) {
+	switch x.kem {
+	case HpkeDhkemP256:
+		return ecdh.P256(), nil
+	case HpkeDhkemX25519:
+		return ecdh.X25519(), nil
+	default:
+		return nil, ErrInvalidHpkeSuite
+	}
+}
+
+func (x *Hpke) kdfHash() func() hash.Hash {
+	switch x.kdf {
+	case HpkeHkdfSha256:
+		return sha256.New
+	case HpkeHkdfSha512:
+		return sha512.New
+	default:
+		return nil
+	}
+}
+
+func (x *Hpke) keySize() int {
+	switch x.aead {
+	case HpkeAes128Gcm:
+		return 16
+	case HpkeAes256Gcm:
+		return 32
+	default:
+		return 0
+	}
+}
+
+func (x *Hpke) kemSuiteID() []byte {
+	return binary.BigEndian.AppendUint16([]byte("KEM"), uint16(x.kem))
+}
+
+func (x *Hpke) suiteID() []byte {
+	id := binary.BigEndian.AppendUint16([]byte("HPKE"), uint16(x.kem))
+	id = binary.BigEndian.AppendUint16(id, uint16(x.kdf))
+	return binary.BigEndian.AppendUint16(id, uint16(x.aead))
+}
+
+// labeledExtract and labeledExpand implement RFC 9180 section 4
+func labeledExtract(h func() hash.Hash, suiteID, salt []byte, label string, ikm []byte) ([]byte, error) {
+	labeled := make([]byte, 0, 7+len(suiteID)+len(label)+len(ikm))
+	labeled = append(labeled, "HPKE-v1"...)
+	labeled = append(labeled, suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, ikm...)
+	return hkdf.Extract(h, labeled, salt)
+}
+
+func labeledExpand(h func() hash.Hash, suiteID, prk []byte, label string, info []byte, length int) ([]byte, error) {
+	labeled := make([]byte, 0, 9+len(suiteID)+len(label)+len(info))
+	labeled = binary.BigEndian.AppendUint16(labeled, uint16(length))
+	labeled = append(labeled, "HPKE-v1"...)
+	labeled = append(labeled, suiteID...)
+	labeled = append(labeled, label...)
+	labeled = append(labeled, info...)
+	return hkdf.Expand(h, prk, string(labeled), length)
+}
+
+// DeriveKeyPair deterministically derives a KEM key pair from ikm (RFC 9180 section 7.1.3)
+func (x *Hpke) DeriveKeyPair(ikm []byte) (*keystore.EcPrivateKey, error) {
+	curve, err := x.curve()
+	if err != nil {
+		return nil, err
+	}
+	prk, err := labeledExtract(sha256.New, x.kemSuiteID(), nil, "dkp_prk", ikm)
+	if err != nil {
+		return nil, err
+	}
+	if x.kem == HpkeDhkemX25519 {
+		sk, err := labeledExpand(sha256.New, x.kemSuiteID(), prk, "sk", nil, 32)
+		if err != nil {
+			return nil, err
+		}
+		key, err := curve.NewPrivateKey(sk)
+		if err != nil {
+			return nil, err
+		}
+		return &keystore.EcPrivateKey{Key: key}, nil
+	}
+	// rejection sampling, the bitmask of P-256 is 0xff
+	for counter := 0; counter < 256; counter++ {
+		sk, err := labeledExpand(sha256.New, x.kemSuiteID(), prk, "candidate", []byte{byte(counter)}, 32)
+		if err != nil {
+			return nil, err
+		}
+		if key, err := curve.NewPrivateKey(sk); err == nil {
+			return &keystore.EcPrivateKey{Key: key}, nil
+		}
+	}
+	return nil, errors.New("hpke: failed to derive key pair")
+}
+
+// extractAndExpand turns the DH results into the KEM shared secret (RFC 9180 section 4.1)
+func (x *Hpke) extractAndExpand(dh, kemContext []byte) ([]byte, error) {
+	prk, err := labeledExtract(sha256.New, x.kemSuiteID(), nil, "eae_prk", dh)
+	if err != nil {
+		return nil, err
+	}
+	return labeledExpand(sha256.New, x.kemSuiteID(), prk, "shared_secret", kemContext, 32)
+}
+
+func (x *Hpke) checkCurve(key *ecdh.PublicKey) error {
+	curve, err := x.curve()
+	if err != nil {
+		return err
+	}
+	if key.Curve() != curve {
+		return ErrCurveMismatch
+	}
+	return nil
+}
+
+// encap implements Encap and AuthEncap with the ephemeral key skE
+func (x *Hpke) encap(pkR *ecdh.PublicKey, skS *ecdh.PrivateKey, skE *ecdh.PrivateKey) ([]byte, []byte, error) {
+	dh, err := skE.ECDH(pkR)
+	if err != nil {
+		return nil, nil, err
+	}
+	enc := skE.PublicKey().Bytes()
+	kemContext := append(append([]byte{}, enc...), pkR.Bytes()...)
+	if skS != nil {
+		dhS, err := skS.ECDH(pkR)
+		if err != nil {
+			return nil, nil, err
+		}
+		dh = append(dh, dhS...)
+		kemContext = append(kemContext, skS.PublicKey().Bytes()...)
+	}
+	secret, err := x.extractAndExpand(dh, kemContext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return secret, enc, nil
+}
+
+// decap implements Decap and AuthDecap
+func (x *Hpke) decap(enc []byte, skR *ecdh.PrivateKey, pkS *ecdh.PublicKey) ([]byte, error) {
+	pkE, err := skR.Curve().NewPublicKey(enc)
+	if err != nil {
+		return nil, err
+	}
+	dh, err := skR.ECDH(pkE)
+	if err != nil {
+		return nil, err
+	}
+	kemContext := append(append([]byte{}, enc...), skR.PublicKey().Bytes()...)
+	if pkS != nil {
+		dhS, err := skR.ECDH(pkS)
+		if err != nil {
+			return nil, err
+		}
+		dh = append(dh, dhS...)
+		kemContext = append(kemContext, pkS.Bytes()...)
+	}
+	return x.extractAndExpand(dh, kemContext)
+}
+
+// keySchedule implements RFC 9180 section 5.1
+func (x *Hpke) keySchedule(mode HpkeMode, secret, info []byte, p *hpkeParams, sender bool) (*HpkeContext, error) {
+	h := x.kdfHash()
+	suiteID := x.suiteID()
+	pskIDHash, err := labeledExtract(h, suiteID, nil, "psk_id_hash", p.pskID)
+	if err != nil {
+		return nil, err
+	}
+	infoHash, err := labeledExtract(h, suiteID, nil, "info_hash", info)
+	if err != nil {
+		return nil, err
+	}
+	context := append(append([]byte{byte(mode)}, pskIDHash...), infoHash...)
+
+	schedule, err := labeledExtract(h, suiteID, secret, "secret", p.psk)
+	if err != nil {
+		return nil, err
+	}
+	key, err := labeledExpand(h, suiteID, schedule, "key", context, x.keySize())
+	if err != nil {
+		return nil, err
+	}
+	baseNonce, err := labeledExpand(h, suiteID, schedule, "base_nonce", context, GCMNonceSize)
+	if err != nil {
+		return nil, err
+	}
+	exporterSecret, err := labeledExpand(h, suiteID, schedule, "exp", context, h().Size())
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &HpkeContext{
+		suite:          x,
+		aead:           aead,
+		baseNonce:      baseNonce,
+		exporterSecret: exporterSecret,
+		sender:         sender,
+	}, nil
+}
+
+// SetupSender encapsulates a fresh shared secret to pkR and returns it with the sender context
+func (x *Hpke) SetupSender(pkR *keystore.EcPublicKey, info []byte, opts ...HpkeOption) ([]byte, *HpkeContext, error) {
+	curve, err := x.curve()
+	if err != nil {
+		return nil, nil, err
+	}
+	skE, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return x.setupSender(pkR, info, skE, opts)
+}
+
+func (x *Hpke) setupSender(pkR *keystore.EcPublicKey, info []byte, skE *ecdh.PrivateKey, opts []HpkeOption) ([]byte, *HpkeContext, error) {
+	p := &hpkeParams{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	mode, err := p.mode()
+	if err != nil {
+		return nil, nil, err
+	}
+	if pkR == nil || (p.senderPub != nil && p.senderKey == nil) {
+		return nil, nil, ErrMissingEcKey
+	}
+	if err := x.checkCurve(pkR.Key); err != nil {
+		return nil, nil, err
+	}
+	var skS *ecdh.PrivateKey
+	if p.senderKey != nil {
+		skS = p.senderKey.Key
+		if err := x.checkCurve(skS.PublicKey()); err != nil {
+			return nil, nil, err
+		}
+	}
+	secret, enc, err := x.encap(pkR.Key, skS, skE)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, err := x.keySchedule(mode, secret, info, p, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, ctx, nil
+}
+
+// SetupReceiver decapsulates enc with skR and returns the receiver context
+func (x *Hpke) SetupReceiver(skR *keystore.EcPrivateKey, enc, info []byte, opts ...HpkeOption) (*HpkeContext, error) {
+	p := &hpkeParams{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	mode, err := p.mode()
+	if err != nil {
+		return nil, err
+	}
+	if skR == nil || (p.senderKey != nil && p.senderPub == nil) {
+		return nil, ErrMissingEcKey
+	}
+	if err := x.checkCurve(skR.Key.PublicKey()); err != nil {
+		return nil, err
+	}
+	var pkS *ecdh.PublicKey
+	if p.senderPub != nil {
+		pkS = p.senderPub.Key
+		if err := x.checkCurve(pkS); err != nil {
+			return nil, err
+		}
+	}
+	secret, err := x.decap(enc, skR.Key, pkS)
+	if err != nil {
+		return nil, err
+	}
+	return x.keySchedule(mode, secret, info, p, false)
+}
+
+// Seal encrypts a single message to pkR and returns the encapsulated key and the ciphertext
+func (x *Hpke) Seal(pkR *keystore.EcPublicKey, info, aad, plaintext []byte, opts ...HpkeOption) ([]byte, []byte, error) {
+	enc, ctx, err := x.SetupSender(pkR, info, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	ciphertext, err := ctx.Seal(aad, plaintext)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, ciphertext, nil
+}
+
+// Open decrypts a single message produced by Seal
+func (x *Hpke) Open(skR *keystore.EcPrivateKey, enc, info, aad, ciphertext []byte, opts ...HpkeOption) ([]byte, error) {
+	ctx, err := x.SetupReceiver(skR, enc, info, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.Open(aad, ciphertext)
+}
+
+// HpkeContext encrypts or decrypts a sequence of messages and exports secrets.
+// It is safe for concurrent use, messages are numbered in the order Seal or Open is called.
+type HpkeContext struct {
+	mu             sync.Mutex
+	suite          *Hpke
+	aead           cipher.AEAD
+	baseNonce      []byte
+	exporterSecret []byte
+	seq            uint64
+	sender         bool
+}
+
+// nextNonce xors the sequence number into the base nonce (RFC 9180 section 5.2)
+func (c *HpkeContext) nextNonce() ([]byte, error) {
+	if c.seq == math.MaxUint64 {
+		return nil, ErrNonceExhausted
+	}
+	nonce := append([]byte{}, c.baseNonce...)
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], c.seq)
+	subtle.XORBytes(nonce[len(nonce)-8:], nonce[len(nonce)-8:], seq[:])
+	return nonce, nil
+}
+
+// Seal encrypts the next message of a sender context
+func (c *HpkeContext) Seal(aad, plaintext []byte) ([]byte, error) {
+	if !c.sender {
+		return nil, ErrInvalidHpkeContext
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	nonce, err := c.nextNonce()
+	if err != nil {
+		return nil, err
+	}
+	c.seq++
+	return c.aead.Seal(nil, nonce, plaintext, aad), nil
+}
+
+// Open decrypts the next message of a receiver context,
+// the sequence number only advances when the message is authentic
+func (c *HpkeContext) Open(aad, ciphertext []byte) ([]byte, error) {
+	if c.sender {
+		return nil, ErrInvalidHpkeContext
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	nonce, err := c.nextNonce()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, err
+	}
+	c.seq++
+	return plaintext, nil
+}
+
+// Export derives a secret of length bytes bound to exporterContext (RFC 9180 section 5.3)
+func (c *HpkeContext) Export(exporterContext []byte, length int) ([]byte, error) {
+	h := c.suite.kdfHash()
+	if length <= 0 || length > 255*h().Size() {
+		return nil, ErrInvalidExportLength
+	}
+	return labeledExpand(h, c.suite.suiteID(), c.exporterSecret, "sec", exporterContext, length)
+}
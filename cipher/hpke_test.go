@@ -0,0 +1,177 @@
+package cipher
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/priyanshujain/crypto/keystore"
+)
+
+// RFC 9180 test vectors for the supported suites, from
+// https://github.com/cfrg/draft-irtf-cfrg-hpke/blob/5f503c5/test-vectors.json
+// trimmed to the first three encryptions of every vector
+type hpkeTestVector struct {
+	Mode           HpkeMode `json:"mode"`
+	Kem            HpkeKem  `json:"kem_id"`
+	Kdf            HpkeKdf  `json:"kdf_id"`
+	Aead           HpkeAead `json:"aead_id"`
+	Info           string   `json:"info"`
+	IkmR           string   `json:"ikmR"`
+	IkmS           string   `json:"ikmS"`
+	IkmE           string   `json:"ikmE"`
+	SkRm           string   `json:"skRm"`
+	SkEm           string   `json:"skEm"`
+	Psk            string   `json:"psk"`
+	PskID          string   `json:"psk_id"`
+	PkSm           string   `json:"pkSm"`
+	Enc            string   `json:"enc"`
+	BaseNonce      string   `json:"base_nonce"`
+	ExporterSecret string   `json:"exporter_secret"`
+	Encryptions    []struct {
+		Aad string `json:"aad"`
+		Ct  string `json:"ct"`
+		Pt  string `json:"pt"`
+	} `json:"encryptions"`
+	Exports []struct {
+		Context string `json:"exporter_context"`
+		L       int    `json:"L"`
+		Value   string `json:"exported_value"`
+	} `json:"exports"`
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestHpkeVectors(t *testing.T) {
+	data, err := os.ReadFile("testdata/hpke-rfc9180.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var vectors []hpkeTestVector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vectors {
+		t.Run(fmt.Sprintf("mode=%d kem=%#x kdf=%d aead=%d", v.Mode, v.Kem, v.Kdf, v.Aead), func(t *testing.T) {
+			suite, err := NewHpke(v.Kem, v.Kdf, v.Aead)
+			if err != nil {
+				t.Fatalf("NewHpke() error = %v", err)
+			}
+			skR, err := suite.DeriveKeyPair(mustDecodeHex(t, v.IkmR))
+			if err != nil {
+				t.Fatalf("DeriveKeyPair() error = %v", err)
+			}
+			if hex.EncodeToString(skR.Key.Bytes()) != v.SkRm {
+				t.Fatalf("DeriveKeyPair() = %x, want %s", skR.Key.Bytes(), v.SkRm)
+			}
+			skE, _ := suite.DeriveKeyPair(mustDecodeHex(t, v.IkmE))
+			if hex.EncodeToString(skE.Key.Bytes()) != v.SkEm {
+				t.Fatalf("DeriveKeyPair() = %x, want %s", skE.Key.Bytes(), v.SkEm)
+			}
+
+			var senderOpts, receiverOpts []HpkeOption
+			if v.Mode == HpkeModePsk || v.Mode == HpkeModeAuthPsk {
+				psk := WithHpkePsk(mustDecodeHex(t, v.Psk), mustDecodeHex(t, v.PskID))
+				senderOpts = append(senderOpts, psk)
+				receiverOpts = append(receiverOpts, psk)
+			}
+			if v.Mode == HpkeModeAuth || v.Mode == HpkeModeAuthPsk {
+				skS, _ := suite.DeriveKeyPair(mustDecodeHex(t, v.IkmS))
+				if hex.EncodeToString(skS.PublicKey().Key.Bytes()) != v.PkSm {
+					t.Fatalf("DeriveKeyPair() public key = %x, want %s", skS.PublicKey().Key.Bytes(), v.PkSm)
+				}
+				senderOpts = append(senderOpts, WithHpkeSenderKey(skS))
+				receiverOpts = append(receiverOpts, WithHpkeSenderPublicKey(skS.PublicKey()))
+			}
+
+			info := mustDecodeHex(t, v.Info)
+			enc, sender, err := suite.setupSender(skR.PublicKey(), info, skE.Key, senderOpts)
+			if err != nil {
+				t.Fatalf("setupSender() error = %v", err)
+			}
+			if hex.EncodeToString(enc) != v.Enc {
+				t.Errorf("enc = %x, want %s", enc, v.Enc)
+			}
+			if hex.EncodeToString(sender.baseNonce) != v.BaseNonce {
+				t.Errorf("base_nonce = %x, want %s", sender.baseNonce, v.BaseNonce)
+			}
+			if hex.EncodeToString(sender.exporterSecret) != v.ExporterSecret {
+				t.Errorf("exporter_secret = %x, want %s", sender.exporterSecret, v.ExporterSecret)
+			}
+			receiver, err := suite.SetupReceiver(skR, enc, info, receiverOpts...)
+			if err != nil {
+				t.Fatalf("SetupReceiver() error = %v", err)
+			}
+
+			for i, e := range v.Encryptions {
+				aad, pt := mustDecodeHex(t, e.Aad), mustDecodeHex(t, e.Pt)
+				ct, err := sender.Seal(aad, pt)
+				if err != nil {
+					t.Fatalf("Seal() error = %v", err)
+				}
+				if hex.EncodeToString(ct) != e.Ct {
+					t.Errorf("encryption %d: Seal() = %x, want %s", i, ct, e.Ct)
+				}
+				result, err := receiver.Open(aad, ct)
+				if err != nil {
+					t.Fatalf("encryption %d: Open() error = %v", i, err)
+				}
+				if !bytes.Equal(result, pt) {
+					t.Errorf("encryption %d: Open() = %x, want %x", i, result, pt)
+				}
+			}
+			for i, e := range v.Exports {
+				value, err := receiver.Export(mustDecodeHex(t, e.Context), e.L)
+				if err != nil {
+					t.Fatalf("Export() error = %v", err)
+				}
+				if hex.EncodeToString(value) != e.Value {
+					t.Errorf("export %d: Export() = %x, want %s", i, value, e.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestHpkeSealOpen(t *testing.T) {
+	suite, _ := NewHpke(HpkeDhkemP256, HpkeHkdfSha256, HpkeAes256Gcm)
+	skR, pkR, _ := keystore.GenerateEcKeyPair(keystore.P256)
+	skS, pkS, _ := keystore.GenerateEcKeyPair(keystore.P256)
+	info, aad, plaintext := []byte("partner"), []byte("header"), []byte("{\"name\":\"test\"}")
+
+	enc, ct, err := suite.Seal(pkR, info, aad, plaintext, WithHpkeSenderKey(skS), WithHpkePsk([]byte("0123456789abcdef0123456789abcdef"), []byte("psk-1")))
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	result, err := suite.Open(skR, enc, info, aad, ct, WithHpkeSenderPublicKey(pkS), WithHpkePsk([]byte("0123456789abcdef0123456789abcdef"), []byte("psk-1")))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if !bytes.Equal(result, plaintext) {
+		t.Errorf("got %q, wanted %q", result, plaintext)
+	}
+	if _, err := suite.Open(skR, enc, info, aad, ct, WithHpkeSenderPublicKey(pkS)); err == nil {
+		t.Errorf("Open() without the psk succeeded")
+	}
+	if _, err := suite.Open(skR, enc, info, aad, ct, WithHpkePsk([]byte("psk"), nil)); err != ErrInvalidPsk {
+		t.Errorf("Open() error = %v, want %v", err, ErrInvalidPsk)
+	}
+
+	x25519Key, _, _ := keystore.GenerateEcKeyPair(keystore.X25519)
+	if _, _, err := suite.Seal(x25519Key.PublicKey(), info, aad, plaintext); err != ErrCurveMismatch {
+		t.Errorf("Seal() error = %v, want %v", err, ErrCurveMismatch)
+	}
+	if _, err := NewHpke(HpkeDhkemP256, HpkeHkdfSha256, 3); err != ErrInvalidHpkeSuite {
+		t.Errorf("NewHpke() error = %v, want %v", err, ErrInvalidHpkeSuite)
+	}
+}
@@ -0,0 +1,84 @@
+package cipher
+
+// AES Key Wrap
+// https://datatracker.ietf.org/doc/html/rfc3394
+// It protects keys with a key encryption key, used by JWE (A128KW, A256KW) and CMS.
+
+import (
+	"crypto/aes"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// default initial value (RFC 3394 section 2.2.3.1)
+var keyWrapIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+
+// key wrap errors
+var (
+	// ErrInvalidKeySize indicates a key to wrap which is not a multiple of 64 bits or shorter than 128 bits
+	ErrInvalidKeySize = errors.New("invalid key size for key wrap")
+
+	// ErrKeyUnwrap indicates a wrapped key which fails the integrity check
+	ErrKeyUnwrap = errors.New("key unwrap failed")
+)
+
+// WrapKey wraps key with the AES key encryption key kek
+func WrapKey(kek, key []byte) ([]byte, error) {
+	if len(key) < 16 || len(key)%8 != 0 {
+		return nil, ErrInvalidKeySize
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(key) / 8
+	dst := make([]byte, 8+len(key))
+	copy(dst, keyWrapIV)
+	copy(dst[8:], key)
+
+	b := make([]byte, aes.BlockSize)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(b, dst[:8])
+			copy(b[8:], dst[i*8:(i+1)*8])
+			block.Encrypt(b, b)
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(dst[:8], binary.BigEndian.Uint64(b[:8])^t)
+			copy(dst[i*8:(i+1)*8], b[8:])
+		}
+	}
+	return dst, nil
+}
+
+// UnwrapKey unwraps a key wrapped by WrapKey and checks its integrity
+func UnwrapKey(kek, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 24 || len(wrapped)%8 != 0 {
+		return nil, ErrInvalidKeySize
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+	n := len(wrapped)/8 - 1
+	a := make([]byte, 8)
+	copy(a, wrapped[:8])
+	r := make([]byte, len(wrapped)-8)
+	copy(r, wrapped[8:])
+
+	b := make([]byte, aes.BlockSize)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(b[:8], binary.BigEndian.Uint64(a)^t)
+			copy(b[8:], r[(i-1)*8:i*8])
+			block.Decrypt(b, b)
+			copy(a, b[:8])
+			copy(r[(i-1)*8:i*8], b[8:])
+		}
+	}
+	if subtle.ConstantTimeCompare(a, keyWrapIV) != 1 {
+		return nil, ErrKeyUnwrap
+	}
+	return r, nil
+}
@@ -0,0 +1,59 @@
+package cipher
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// Tests from RFC 3394 section 4
+// https://datatracker.ietf.org/doc/html/rfc3394#section-4
+var keyWrapTestCases = []struct {
+	name    string
+	kek     string
+	key     string
+	wrapped string
+}{
+	{
+		name:    "128-bit key with 128-bit kek",
+		kek:     "000102030405060708090a0b0c0d0e0f",
+		key:     "00112233445566778899aabbccddeeff",
+		wrapped: "1fa68b0a8112b447aef34bd8fb5a7b829d3e862371d2cfe5",
+	},
+	{
+		name:    "128-bit key with 256-bit kek",
+		kek:     "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		key:     "00112233445566778899aabbccddeeff",
+		wrapped: "64e8c3f9ce0f5ba263e9777905818a2a93c8191e7d6e8ae7",
+	},
+	{
+		name:    "256-bit key with 256-bit kek",
+		kek:     "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f",
+		key:     "00112233445566778899aabbccddeeff000102030405060708090a0b0c0d0e0f",
+		wrapped: "28c9f404c4b810f4cbccb35cfb87f8263f5786e2d80ed326cbc7f0e71a99f43bfb988b9b7a02dd21",
+	},
+}
+
+func TestKeyWrap(t *testing.T) {
+	for _, test := range keyWrapTestCases {
+		kek, _ := hex.DecodeString(test.kek)
+		key, _ := hex.DecodeString(test.key)
+		wrapped, err := WrapKey(kek, key)
+		if err != nil {
+			t.Fatalf("%s: WrapKey() error = %v", test.name, err)
+		}
+		if hex.EncodeToString(wrapped) != test.wrapped {
+			t.Errorf("%s: WrapKey() = %x, want %s", test.name, wrapped, test.wrapped)
+		}
+		unwrapped, err := UnwrapKey(kek, wrapped)
+		if err != nil {
+			t.Fatalf("%s: UnwrapKey() error = %v", test.name, err)
+		}
+		if hex.EncodeToString(unwrapped) != test.key {
+			t.Errorf("%s: UnwrapKey() = %x, want %s", test.name, unwrapped, test.key)
+		}
+		wrapped[0] ^= 1
+		if _, err := UnwrapKey(kek, wrapped); err != ErrKeyUnwrap {
+			t.Errorf("%s: UnwrapKey() error = %v, want %v", test.name, err, ErrKeyUnwrap)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// It implements encryption to several RSA public keys.
+// The payload is encrypted once with a random AES-256-GCM key, which is wrapped with
+// RSA-OAEP (SHA256) for every recipient. The header lists the recipients by key fingerprint
+// so a recipient finds its slot directly:
+//
+//	version (1 byte) || count (2 bytes) || count * (fingerprint (32 bytes) || length (2 bytes) || wrapped key)
+//	|| nonce || AES-GCM ciphertext || tag
+//
+// The header is authenticated as additional data of the payload.
+package cipher
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/priyanshujain/crypto/keystore"
+)
+
+const (
+	multiRecipientVersion = 1
+	multiRecipientKeySize = 32
+)
+
+// errors
+var (
+	// ErrNoRecipients indicates a MultiRecipient encryptor without recipients
+	ErrNoRecipients = errors.New("no recipients")
+
+	// ErrTooManyRecipients indicates more recipients than the 16 bit header count can hold
+	ErrTooManyRecipients = errors.New("too many recipients")
+
+	// ErrRecipientNotFound indicates a ciphertext which is not encrypted to the private key
+	ErrRecipientNotFound = errors.New("ciphertext has no slot for the private key")
+
+	// ErrInvalidHeader indicates a malformed multi-recipient header
+	ErrInvalidHeader = errors.New("invalid multi-recipient header")
+)
+
+type MultiRecipient struct {
+	privateKey *keystore.PrivateKey
+	recipients []*keystore.PublicKey
+}
+
+var _ BlockMode = (*MultiRecipient)(nil)
+
+// RecipientSlot is the wrapped payload key of one recipient
+type RecipientSlot struct {
+	Fingerprint []byte // keystore.PublicKey.Fingerprint of the recipient
+	WrappedKey  []byte
+}
+
+// NewMultiRecipientEncryptor returns a MultiRecipient which encrypts to all recipients
+func NewMultiRecipientEncryptor(recipients ...*keystore.PublicKey) (*MultiRecipient, error) {
+	if len(recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+	if len(recipients) > math.MaxUint16 {
+		return nil, ErrTooManyRecipients
+	}
+	for _, pub := range recipients {
+		if pub == nil {
+			return nil, ErrMissingPublicKey
+		}
+	}
+	return &MultiRecipient{recipients: recipients}, nil
+}
+
+// NewMultiRecipientDecryptor returns a MultiRecipient which decrypts the slot of priv
+func NewMultiRecipientDecryptor(priv *keystore.PrivateKey) (*MultiRecipient, error) {
+	if priv == nil {
+		return nil, ErrMissingPrivateKey
+	}
+	return &MultiRecipient{privateKey: priv}, nil
+}
+
+// encrypts bytes array to all recipients
+func (x *MultiRecipient) Encrypt(src []byte) ([]byte, error) {
+	if len(x.recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+	key := make([]byte, multiRecipientKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+
+	header := []byte{multiRecipientVersion}
+	header = binary.BigEndian.AppendUint16(header, uint16(len(x.recipients)))
+	for _, pub := range x.recipients {
+		fingerprint, err := pub.Fingerprint()
+		if err != nil {
+			return nil, err
+		}
+		encryptor, err := NewRsaEncryptor(pub)
+		if err != nil {
+			return nil, err
+		}
+		wrapped, err := encryptor.Encrypt(key)
+		if err != nil {
+			return nil, err
+		}
+		header = append(header, fingerprint...)
+		header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+		header = append(header, wrapped...)
+	}
+
+	aead, err := multiRecipientAead(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	dst := make([]byte, 0, len(header)+len(nonce)+len(src)+aead.Overhead())
+	dst = append(dst, header...)
+	dst = append(dst, nonce...)
+	return aead.Seal(dst, nonce, src, header), nil
+}
+
+// decrypts bytes array with the slot of the private key
+func (x *MultiRecipient) Decrypt(src []byte) ([]byte, error) {
+	if x.privateKey == nil {
+		return nil, ErrMissingPrivateKey
+	}
+	slots, headerSize, err := parseRecipientSlots(src)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint, err := x.privateKey.PublicKey().Fingerprint()
+	if err != nil {
+		return nil, err
+	}
+	var slot *RecipientSlot
+	for i := range slots {
+		if bytes.Equal(slots[i].Fingerprint, fingerprint) {
+			slot = &slots[i]
+			break
+		}
+	}
+	if slot == nil {
+		return nil, ErrRecipientNotFound
+	}
+
+	decryptor, err := NewRsaDecryptor(x.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	// a slot which does not decrypt yields a random key and fails authentication below
+	key, err := decryptor.DecryptSessionKey(slot.WrappedKey, multiRecipientKeySize)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := multiRecipientAead(key)
+	if err != nil {
+		return nil, err
+	}
+	header, payload := src[:headerSize], src[headerSize:]
+	if len(payload) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrShortBlock
+	}
+	nonce, payload := payload[:aead.NonceSize()], payload[aead.NonceSize():]
+	return aead.Open(nil, nonce, payload, header)
+}
+
+// MultiRecipientSlots returns the recipient slots from the header of a ciphertext
+func MultiRecipientSlots(src []byte) ([]RecipientSlot, error) {
+	slots, _, err := parseRecipientSlots(src)
+	return slots, err
+}
+
+func parseRecipientSlots(src []byte) ([]RecipientSlot, int, error) {
+	if len(src) < 3 || src[0] != multiRecipientVersion {
+		return nil, 0, ErrInvalidHeader
+	}
+	count := int(binary.BigEndian.Uint16(src[1:3]))
+	offset := 3
+	slots := make([]RecipientSlot, 0, count)
+	for i := 0; i < count; i++ {
+		if len(src) < offset+sha256.Size+2 {
+			return nil, 0, ErrInvalidHeader
+		}
+		fingerprint := src[offset : offset+sha256.Size]
+		offset += sha256.Size
+		size := int(binary.BigEndian.Uint16(src[offset : offset+2]))
+		offset += 2
+		if len(src) < offset+size {
+			return nil, 0, ErrInvalidHeader
+		}
+		slots = append(slots, RecipientSlot{Fingerprint: fingerprint, WrappedKey: src[offset : offset+size]})
+		offset += size
+	}
+	return slots, offset, nil
+}
+
+func multiRecipientAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
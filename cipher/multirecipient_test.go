@@ -0,0 +1,86 @@
+package cipher
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/priyanshujain/crypto/keystore"
+)
+
+func TestMultiRecipient(t *testing.T) {
+	plaintext := []byte("{\"requestId\":\"23\",\"actionName\":\"SELLER_SETTLEMENT_STATUS\"}")
+	rsaPrivateKey, _ := keystore.ParsePrivateKeyFromPem([]byte(privateKeyPem))
+	privateKeys := []*keystore.PrivateKey{rsaPrivateKey}
+	for i := 0; i < 2; i++ {
+		privateKey, _, err := keystore.GenerateKeyPair(2048)
+		if err != nil {
+			t.Fatalf("GenerateKeyPair() error = %v", err)
+		}
+		privateKeys = append(privateKeys, privateKey)
+	}
+	var publicKeys []*keystore.PublicKey
+	for _, privateKey := range privateKeys {
+		publicKeys = append(publicKeys, privateKey.PublicKey())
+	}
+
+	encryptor, err := NewMultiRecipientEncryptor(publicKeys...)
+	if err != nil {
+		t.Fatalf("NewMultiRecipientEncryptor() error = %v", err)
+	}
+	ciphertext, err := encryptor.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	slots, err := MultiRecipientSlots(ciphertext)
+	if err != nil {
+		t.Fatalf("MultiRecipientSlots() error = %v", err)
+	}
+	if len(slots) != len(publicKeys) {
+		t.Fatalf("len(slots) = %d, want %d", len(slots), len(publicKeys))
+	}
+	for i, privateKey := range privateKeys {
+		t.Run(fmt.Sprintf("recipient %d", i), func(t *testing.T) {
+			fingerprint, _ := publicKeys[i].Fingerprint()
+			if !bytes.Equal(slots[i].Fingerprint, fingerprint) {
+				t.Errorf("slot fingerprint = %x, want %x", slots[i].Fingerprint, fingerprint)
+			}
+			decryptor, _ := NewMultiRecipientDecryptor(privateKey)
+			result, err := decryptor.Decrypt(ciphertext)
+			if err != nil {
+				t.Fatalf("Decrypt() error = %v", err)
+			}
+			if !bytes.Equal(result, plaintext) {
+				t.Errorf("got %q, wanted %q", result, plaintext)
+			}
+		})
+	}
+
+	outsider, _, _ := keystore.GenerateKeyPair(2048)
+	decryptor, _ := NewMultiRecipientDecryptor(outsider)
+	if _, err := decryptor.Decrypt(ciphertext); err != ErrRecipientNotFound {
+		t.Errorf("Decrypt() error = %v, want %v", err, ErrRecipientNotFound)
+	}
+
+	// the header is authenticated, dropping a recipient breaks the payload
+	decryptor, _ = NewMultiRecipientDecryptor(privateKeys[0])
+	tampered := append([]byte{}, ciphertext...)
+	tampered[2]--
+	if _, err := decryptor.Decrypt(tampered); err == nil {
+		t.Errorf("Decrypt() of a tampered header succeeded")
+	}
+	if _, err := decryptor.Decrypt(ciphertext[:40]); err != ErrInvalidHeader {
+		t.Errorf("Decrypt() error = %v, want %v", err, ErrInvalidHeader)
+	}
+	if _, err := NewMultiRecipientEncryptor(); err != ErrNoRecipients {
+		t.Errorf("NewMultiRecipientEncryptor() error = %v, want %v", err, ErrNoRecipients)
+	}
+	tooMany := make([]*keystore.PublicKey, math.MaxUint16+1)
+	for i := range tooMany {
+		tooMany[i] = publicKeys[0]
+	}
+	if _, err := NewMultiRecipientEncryptor(tooMany...); err != ErrTooManyRecipients {
+		t.Errorf("NewMultiRecipientEncryptor() error = %v, want %v", err, ErrTooManyRecipients)
+	}
+}
@@ -0,0 +1,274 @@
+package cipher
+
+// Nonce generation for AES-GCM
+// GCM fails catastrophically when a nonce is reused with the same key, so every
+// nonce is handed out by a NonceGenerator that also enforces the invocation limit
+// of the key (NIST SP 800-38D §8.3).
+// https://nvlpubs.nist.gov/nistpubs/Legacy/SP/nistspecialpublication800-38d.pdf
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// GCMNonceSize is the size of the 96-bit nonce recommended for GCM
+const GCMNonceSize = 12
+
+// RandomNonceLimit is the maximum number of invocations of a key with random nonces (SP 800-38D §8.3)
+const RandomNonceLimit = 1 << 32
+
+// number of counter values reserved from a NonceStore at once
+const nonceReserveBatch = 1024
+
+// nonce errors
+var (
+	// ErrNonceExhausted indicates that the invocation limit of the key has been reached
+	ErrNonceExhausted = errors.New("nonce invocation limit reached")
+
+	// ErrInvalidFixedField indicates that the fixed field does not leave a 32 to 64 bit invocation field
+	ErrInvalidFixedField = errors.New("invalid nonce fixed field size")
+
+	// ErrNoNonceGenerator indicates GCM encryption without a nonce generator
+	ErrNoNonceGenerator = errors.New("missing nonce generator")
+)
+
+// NonceGenerator returns a fresh nonce on every call until the invocation limit
+// of the key is reached, after which it returns ErrNonceExhausted.
+type NonceGenerator interface {
+	Nonce() ([]byte, error)
+}
+
+// NonceStore persists invocation counters per key.
+// Reserve atomically advances the counter of keyID by n and returns its previous value.
+type NonceStore interface {
+	Reserve(keyID string, n uint64) (uint64, error)
+}
+
+// invocations counts the invocations of a key up to its limit. With a store the
+// counter is reserved from it in batches, values left unused at shutdown are skipped.
+type invocations struct {
+	mu    sync.Mutex
+	next  uint64
+	end   uint64
+	limit uint64
+	store NonceStore
+	keyID string
+}
+
+func (c *invocations) init(limit uint64, store NonceStore, keyID string) {
+	c.limit = limit
+	c.store = store
+	c.keyID = keyID
+	if store == nil {
+		c.end = limit
+	}
+}
+
+// take returns the next invocation counter
+func (c *invocations) take() (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.next >= c.end {
+		if c.store == nil {
+			return 0, ErrNonceExhausted
+		}
+		start, err := c.store.Reserve(c.keyID, nonceReserveBatch)
+		if err != nil {
+			return 0, err
+		}
+		if start >= c.limit {
+			return 0, ErrNonceExhausted
+		}
+		c.next = start
+		c.end = c.limit
+		if c.limit-start > nonceReserveBatch {
+			c.end = start + nonceReserveBatch
+		}
+	}
+	n := c.next
+	c.next++
+	return n, nil
+}
+
+type randomNonce struct {
+	invocations
+}
+
+// NewRandomNonce returns a generator of random 96-bit nonces (SP 800-38D §8.2.2).
+// limit 0 uses RandomNonceLimit. The invocations are only counted in memory by this
+// generator, use NewPersistentRandomNonce to enforce the limit of a key across restarts.
+func NewRandomNonce(limit uint64) NonceGenerator {
+	return newRandomNonce(limit, nil, "")
+}
+
+// NewPersistentRandomNonce returns a generator of random 96-bit nonces like NewRandomNonce
+// whose invocation count is kept in store under keyID, so the limit of the key holds
+// across restarts. Invocations are reserved in batches, values left unused at shutdown are skipped.
+func NewPersistentRandomNonce(store NonceStore, keyID string, limit uint64) NonceGenerator {
+	return newRandomNonce(limit, store, keyID)
+}
+
+func newRandomNonce(limit uint64, store NonceStore, keyID string) *randomNonce {
+	if limit == 0 || limit > RandomNonceLimit {
+		limit = RandomNonceLimit
+	}
+	g := &randomNonce{}
+	g.init(limit, store, keyID)
+	return g
+}
+
+func (g *randomNonce) Nonce() ([]byte, error) {
+	if _, err := g.take(); err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, GCMNonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+type counterNonce struct {
+	invocations
+	fixed []byte
+}
+
+// NewCounterNonce returns a deterministic generator (SP 800-38D §8.2.1) whose nonce is
+// the fixed field followed by a big-endian invocation counter starting at zero.
+// The fixed field must be 4 to 8 bytes long, limit 0 allows every value of the invocation field.
+func NewCounterNonce(fixed []byte, limit uint64) (NonceGenerator, error) {
+	return newCounterNonce(fixed, limit, nil, "")
+}
+
+// NewPersistentNonce returns a deterministic generator like NewCounterNonce whose
+// invocation counter is kept in store under keyID, so it keeps growing across restarts.
+// Counter values are reserved in batches, values left unused at shutdown are skipped.
+func NewPersistentNonce(store NonceStore, keyID string, fixed []byte, limit uint64) (NonceGenerator, error) {
+	return newCounterNonce(fixed, limit, store, keyID)
+}
+
+func newCounterNonce(fixed []byte, limit uint64, store NonceStore, keyID string) (*counterNonce, error) {
+	invocationSize := GCMNonceSize - len(fixed)
+	if invocationSize < 4 || invocationSize > 8 {
+		return nil, ErrInvalidFixedField
+	}
+	maxCount := uint64(math.MaxUint64)
+	if invocationSize < 8 {
+		maxCount = 1 << (8 * uint(invocationSize))
+	}
+	if limit == 0 || limit > maxCount {
+		limit = maxCount
+	}
+	g := &counterNonce{fixed: append([]byte{}, fixed...)}
+	g.init(limit, store, keyID)
+	return g, nil
+}
+
+func (g *counterNonce) Nonce() ([]byte, error) {
+	n, err := g.take()
+	if err != nil {
+		return nil, err
+	}
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], n)
+
+	nonce := make([]byte, 0, GCMNonceSize)
+	nonce = append(nonce, g.fixed...)
+	return append(nonce, counter[8-(GCMNonceSize-len(g.fixed)):]...), nil
+}
+
+// MemoryNonceStore keeps counters in memory, it is mostly useful for tests.
+type MemoryNonceStore struct {
+	mu       sync.Mutex
+	counters map[string]uint64
+}
+
+// NewMemoryNonceStore returns an empty in-memory NonceStore
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{counters: make(map[string]uint64)}
+}
+
+func (s *MemoryNonceStore) Reserve(keyID string, n uint64) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	start := s.counters[keyID]
+	if start > math.MaxUint64-n {
+		return 0, ErrNonceExhausted
+	}
+	s.counters[keyID] = start + n
+	return start, nil
+}
+
+// FileNonceStore keeps one counter file per key in a directory.
+// Counters are written to a temporary file, synced and renamed in place and the
+// directory is synced, so a crash never moves a counter backwards.
+// It is safe for concurrent use within one process.
+type FileNonceStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFileNonceStore returns a NonceStore backed by files in dir
+func NewFileNonceStore(dir string) *FileNonceStore {
+	return &FileNonceStore{dir: dir}
+}
+
+func (s *FileNonceStore) Reserve(keyID string, n uint64) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path := filepath.Join(s.dir, hex.EncodeToString([]byte(keyID))+".ctr")
+	var start uint64
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		start, err = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return 0, err
+	}
+	if start > math.MaxUint64-n {
+		return 0, ErrNonceExhausted
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".ctr-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(strconv.FormatUint(start+n, 10)); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return 0, err
+	}
+	// the rename is only durable once the directory entry is synced
+	dir, err := os.Open(s.dir)
+	if err != nil {
+		return 0, err
+	}
+	defer dir.Close()
+	if err := dir.Sync(); err != nil {
+		return 0, err
+	}
+	return start, nil
+}
@@ -0,0 +1,126 @@
+package cipher
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCounterNonce(t *testing.T) {
+	fixed := []byte{0xca, 0xfe, 0xba, 0xbe}
+	gen, err := NewCounterNonce(fixed, 3)
+	if err != nil {
+		t.Fatalf("NewCounterNonce() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		nonce, err := gen.Nonce()
+		if err != nil {
+			t.Fatalf("Nonce() error = %v", err)
+		}
+		want := append([]byte{0xca, 0xfe, 0xba, 0xbe, 0, 0, 0, 0, 0, 0, 0}, byte(i))
+		if !bytes.Equal(nonce, want) {
+			t.Errorf("Nonce() = %x, want %x", nonce, want)
+		}
+	}
+	if _, err := gen.Nonce(); err != ErrNonceExhausted {
+		t.Errorf("Nonce() error = %v, want %v", err, ErrNonceExhausted)
+	}
+}
+
+func TestCounterNonceInvalidFixedField(t *testing.T) {
+	for _, size := range []int{0, 3, 9, 12} {
+		if _, err := NewCounterNonce(make([]byte, size), 0); err != ErrInvalidFixedField {
+			t.Errorf("NewCounterNonce(%d bytes) error = %v, want %v", size, err, ErrInvalidFixedField)
+		}
+	}
+}
+
+func TestRandomNonceLimit(t *testing.T) {
+	gen := NewRandomNonce(2)
+	first, _ := gen.Nonce()
+	second, _ := gen.Nonce()
+	if len(first) != GCMNonceSize || bytes.Equal(first, second) {
+		t.Errorf("Nonce() returned %x and %x", first, second)
+	}
+	if _, err := gen.Nonce(); err != ErrNonceExhausted {
+		t.Errorf("Nonce() error = %v, want %v", err, ErrNonceExhausted)
+	}
+}
+
+func TestPersistentRandomNonce(t *testing.T) {
+	store := NewFileNonceStore(t.TempDir())
+	gen := NewPersistentRandomNonce(store, "key-1", nonceReserveBatch+1)
+	for i := 0; i < nonceReserveBatch; i++ {
+		if _, err := gen.Nonce(); err != nil {
+			t.Fatalf("Nonce() error = %v", err)
+		}
+	}
+
+	// the invocations of the key are counted across restarts
+	gen = NewPersistentRandomNonce(store, "key-1", nonceReserveBatch+1)
+	if _, err := gen.Nonce(); err != nil {
+		t.Fatalf("Nonce() after restart error = %v", err)
+	}
+	gen = NewPersistentRandomNonce(store, "key-1", nonceReserveBatch+1)
+	if _, err := gen.Nonce(); err != ErrNonceExhausted {
+		t.Errorf("Nonce() error = %v, want %v", err, ErrNonceExhausted)
+	}
+}
+
+func TestFileNonceStore(t *testing.T) {
+	dir := t.TempDir()
+	fixed := []byte{1, 2, 3, 4}
+
+	gen, err := NewPersistentNonce(NewFileNonceStore(dir), "key-1", fixed, 0)
+	if err != nil {
+		t.Fatalf("NewPersistentNonce() error = %v", err)
+	}
+	first, _ := gen.Nonce()
+
+	// a restarted process must never hand out a nonce twice
+	gen, _ = NewPersistentNonce(NewFileNonceStore(dir), "key-1", fixed, 0)
+	second, _ := gen.Nonce()
+	if bytes.Compare(second, first) <= 0 {
+		t.Errorf("Nonce() after restart = %x, want greater than %x", second, first)
+	}
+
+	gen, _ = NewPersistentNonce(NewFileNonceStore(dir), "key-1", fixed, 2*nonceReserveBatch)
+	if _, err := gen.Nonce(); err != ErrNonceExhausted {
+		t.Errorf("Nonce() error = %v, want %v", err, ErrNonceExhausted)
+	}
+}
+
+func TestAesGCM(t *testing.T) {
+	key := []byte{160, 153, 156, 74, 55, 224, 78, 74, 56, 176, 207, 163, 173, 44, 109, 211}
+	gen, _ := NewPersistentNonce(NewMemoryNonceStore(), "key-1", []byte{0, 0, 0, 1}, 1)
+	aes, err := NewAesGCM(key, gen)
+	if err != nil {
+		t.Fatalf("NewAesGCM() error = %v", err)
+	}
+	plaintext := []byte("{\"name\":\"test\"}")
+
+	ciphertext, err := aes.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	result, err := aes.Decrypt(ciphertext)
+	if err != nil {
+		t.Fatalf("Decrypt() error = %v", err)
+	}
+	if !bytes.Equal(result, plaintext) {
+		t.Errorf("got %q, wanted %q", result, plaintext)
+	}
+	if _, err := aes.Encrypt(plaintext); err != ErrNonceExhausted {
+		t.Errorf("Encrypt() error = %v, want %v", err, ErrNonceExhausted)
+	}
+
+	if _, err := NewAesGCM(key, nil); err != ErrNoNonceGenerator {
+		t.Errorf("NewAesGCM() error = %v, want %v", err, ErrNoNonceGenerator)
+	}
+	if _, err := NewAesGCM(key[:15], gen); err == nil {
+		t.Error("NewAesGCM() with a 15 byte key succeeded")
+	}
+	aes.nonce = nil
+	if _, err := aes.Encrypt(plaintext); err != ErrNoNonceGenerator {
+		t.Errorf("Encrypt() error = %v, want %v", err, ErrNoNonceGenerator)
+	}
+}
@@ -35,19 +35,14 @@ var (
 	ErrUnsupportedPadding = errors.New("unsupported padding scheme")
 )
 
+// pad returns a padded copy of src, the empty input is padded to a full block
 func pad(src []byte, blockSize int) ([]byte, error) {
-	if blockSize < 1 {
+	if blockSize < 1 || blockSize > 255 {
 		return nil, ErrInvalidBlockSize
 	}
-	if len(src) == 0 {
-		return nil, ErrInvalidData
-	}
 	padLength := blockSize - (len(src) % blockSize)
-	if padLength == 0 {
-		padLength = blockSize
-	}
 	padding := bytes.Repeat([]byte{byte(padLength)}, padLength)
-	return append(src, padding...), nil
+	return append(append(make([]byte, 0, len(src)+padLength), src...), padding...), nil
 }
 
 // unpad validates and unpads data from the given bytes slice.
@@ -65,7 +60,7 @@ func unpad(src []byte, blockSize int) ([]byte, error) {
 	}
 	// the last byte is the length of padding
 	padLength := int(src[len(src)-1])
-	if padLength > len(src) {
+	if padLength == 0 || padLength > blockSize {
 		return nil, ErrInvalidPadding
 	}
 
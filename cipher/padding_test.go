@@ -33,3 +33,41 @@ func TestPad(t *testing.T) {
 		}
 	}
 }
+
+func TestPadEmpty(t *testing.T) {
+	padded, err := AddPadding(nil, PKCS7)
+	if err != nil {
+		t.Fatalf("AddPadding() error = %v", err)
+	}
+	if want := bytes.Repeat([]byte{16}, 16); !bytes.Equal(padded, want) {
+		t.Errorf("AddPadding() = %X, want %X", padded, want)
+	}
+	result, err := TrimPadding(padded, PKCS7)
+	if err != nil || len(result) != 0 {
+		t.Errorf("TrimPadding() = %X, %v, want empty", result, err)
+	}
+
+	// the input is not modified through its spare capacity
+	src := make([]byte, 3, 16)
+	padded, _ = pad(src, 8)
+	padded[0] = 0xff
+	if src[:4][3] != 0 || src[0] != 0 {
+		t.Errorf("pad() modified its input")
+	}
+}
+
+func TestUnpadInvalid(t *testing.T) {
+	tests := [][]byte{
+		// a pad byte of 0
+		{0x1, 0x2, 0x3, 0x4, 0x5, 0x6, 0x7, 0x0},
+		// longer than the block
+		{0x9, 0x9, 0x9, 0x9, 0x9, 0x9, 0x9, 0x9, 0x9, 0x9, 0x9, 0x9, 0x9, 0x9, 0x9, 0x9},
+		// inconsistent pad bytes
+		{0x1, 0x2, 0x3, 0x4, 0x5, 0x6, 0x3, 0x2},
+	}
+	for _, src := range tests {
+		if _, err := unpad(src, 8); err != ErrInvalidPadding {
+			t.Errorf("unpad(%X) error = %v, want %v", src, err, ErrInvalidPadding)
+		}
+	}
+}
@@ -4,7 +4,9 @@ package cipher
 import (
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/subtle"
 	"errors"
+	"io"
 
 	"github.com/priyanshujain/crypto/hash"
 	"github.com/priyanshujain/crypto/keystore"
@@ -21,6 +23,24 @@ const (
 // errors
 var (
 	ErrInvalidEncryptionScheme = errors.New("invalid encryption scheme")
+
+	// ErrMissingPublicKey indicates encryption with an Rsa that only holds a private key
+	ErrMissingPublicKey = errors.New("missing rsa public key")
+
+	// ErrMissingPrivateKey indicates decryption with an Rsa that only holds a public key
+	ErrMissingPrivateKey = errors.New("missing rsa private key")
+
+	// ErrPlaintextTooLong indicates a plaintext longer than MaxPlaintextSize without chunking
+	ErrPlaintextTooLong = errors.New("plaintext too long for rsa key and scheme")
+
+	// ErrInvalidCiphertextSize indicates a chunked ciphertext which is not a multiple of the key size
+	ErrInvalidCiphertextSize = errors.New("invalid rsa ciphertext size")
+
+	// ErrKeyTooSmall indicates an rsa key too small to encrypt any plaintext with the scheme and OAEP hash
+	ErrKeyTooSmall = errors.New("rsa key too small for scheme and hash")
+
+	// ErrInvalidSessionKeySize indicates a session key size which is not positive
+	ErrInvalidSessionKeySize = errors.New("invalid session key size")
 )
 
 type Rsa struct {
@@ -28,35 +48,267 @@ type Rsa struct {
 	publicKey  *keystore.PublicKey
 	scheme     RsaEncryptionScheme
 	hash       hash.HashType
+	mgfHash    hash.HashType // MGF1 hash for OAEP, zero uses hash
+	label      []byte        // OAEP label
+	chunked    bool
+}
+
+// RsaOption configures an Rsa created by NewRsaEncryptor or NewRsaDecryptor
+type RsaOption func(*Rsa)
+
+// WithScheme sets the encryption scheme, the default is OAEP
+func WithScheme(scheme RsaEncryptionScheme) RsaOption {
+	return func(x *Rsa) {
+		x.scheme = scheme
+	}
+}
+
+// WithOAEPHash sets the OAEP hash, the default is SHA256
+func WithOAEPHash(htype hash.HashType) RsaOption {
+	return func(x *Rsa) {
+		x.hash = htype
+	}
+}
+
+// WithMGF1Hash sets the hash used by the OAEP mask generation function MGF1,
+// by default it is the OAEP hash. Java's OAEPWithSHA-256AndMGF1Padding uses SHA1.
+func WithMGF1Hash(htype hash.HashType) RsaOption {
+	return func(x *Rsa) {
+		x.mgfHash = htype
+	}
+}
+
+// WithOAEPLabel sets the OAEP label, it must be equal when encrypting and decrypting
+func WithOAEPLabel(label []byte) RsaOption {
+	return func(x *Rsa) {
+		x.label = label
+	}
+}
+
+// WithChunking enables the legacy mode used by some payment partners: plaintexts
+// longer than MaxPlaintextSize are split into chunks which are encrypted separately
+// and the ciphertexts are concatenated. Decrypt splits the input at the key size.
+func WithChunking() RsaOption {
+	return func(x *Rsa) {
+		x.chunked = true
+	}
+}
+
+// NewRsaEncryptor returns an Rsa which only holds a public key and can only encrypt
+func NewRsaEncryptor(pub *keystore.PublicKey, opts ...RsaOption) (*Rsa, error) {
+	if pub == nil {
+		return nil, ErrMissingPublicKey
+	}
+	return newRsa(nil, pub, opts)
+}
+
+// NewRsaDecryptor returns an Rsa which only holds a private key and can only decrypt
+func NewRsaDecryptor(priv *keystore.PrivateKey, opts ...RsaOption) (*Rsa, error) {
+	if priv == nil {
+		return nil, ErrMissingPrivateKey
+	}
+	return newRsa(priv, nil, opts)
+}
+
+func newRsa(priv *keystore.PrivateKey, pub *keystore.PublicKey, opts []RsaOption) (*Rsa, error) {
+	x := &Rsa{
+		privateKey: priv,
+		publicKey:  pub,
+		scheme:     OAEP,
+		hash:       hash.SHA256,
+	}
+	for _, opt := range opts {
+		opt(x)
+	}
+	switch x.scheme {
+	case PKCS1:
+	case OAEP:
+		if _, err := x.oaepOptions(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrInvalidEncryptionScheme
+	}
+	if _, err := x.MaxPlaintextSize(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func (x *Rsa) oaepOptions() (*rsa.OAEPOptions, error) {
+	stdHash, err := hash.GetStdCryptoHash(x.hash)
+	if err != nil {
+		return nil, err
+	}
+	opts := &rsa.OAEPOptions{Hash: stdHash, Label: x.label}
+	if x.mgfHash != 0 {
+		opts.MGFHash, err = hash.GetStdCryptoHash(x.mgfHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return opts, nil
+}
+
+// MaxPlaintextSize returns the maximum number of bytes a single Encrypt call accepts
+// for the key, scheme and OAEP hash, with chunking it is the size of each chunk.
+// It returns ErrKeyTooSmall when the key can't hold a single byte of plaintext.
+func (x *Rsa) MaxPlaintextSize() (int, error) {
+	var k int
+	switch {
+	case x.publicKey != nil:
+		k = x.publicKey.Key.Size()
+	case x.privateKey != nil:
+		k = x.privateKey.Key.Size()
+	default:
+		return 0, ErrMissingPublicKey
+	}
+	var maxSize int
+	switch x.scheme {
+	case PKCS1:
+		// RFC 8017 section 7.2.1
+		maxSize = k - 11
+	case OAEP:
+		// RFC 8017 section 7.1.1
+		stdHash, err := hash.GetStdCryptoHash(x.hash)
+		if err != nil {
+			return 0, err
+		}
+		maxSize = k - 2*stdHash.Size() - 2
+	default:
+		return 0, ErrInvalidEncryptionScheme
+	}
+	if maxSize < 1 {
+		return 0, ErrKeyTooSmall
+	}
+	return maxSize, nil
 }
 
 // rsa encrypt data using public key
 func (x *Rsa) Encrypt(src []byte) ([]byte, error) {
+	if x.publicKey == nil {
+		return nil, ErrMissingPublicKey
+	}
+	maxSize, err := x.MaxPlaintextSize()
+	if err != nil {
+		return nil, err
+	}
+	if !x.chunked {
+		if len(src) > maxSize {
+			return nil, ErrPlaintextTooLong
+		}
+		return x.encryptBlock(src)
+	}
+
+	dst := make([]byte, 0, (len(src)/maxSize+1)*x.publicKey.Key.Size())
+	for {
+		n := len(src)
+		if n > maxSize {
+			n = maxSize
+		}
+		block, err := x.encryptBlock(src[:n])
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, block...)
+		src = src[n:]
+		if len(src) == 0 {
+			return dst, nil
+		}
+	}
+}
+
+func (x *Rsa) encryptBlock(src []byte) ([]byte, error) {
 	switch x.scheme {
 	case PKCS1:
 		return rsa.EncryptPKCS1v15(rand.Reader, &x.publicKey.Key, src)
 	case OAEP:
-		stdHash, err := hash.GetStdCryptoHash(x.hash)
+		opts, err := x.oaepOptions()
 		if err != nil {
 			return nil, err
 		}
-		return rsa.EncryptOAEP(stdHash.New(), rand.Reader, &x.publicKey.Key, src, nil)
+		return rsa.EncryptOAEPWithOptions(rand.Reader, &x.publicKey.Key, src, opts)
 	default:
 		return nil, ErrInvalidEncryptionScheme
 	}
 }
 
-// rsa encrypt data using private key
+// rsa decrypt data using private key
 func (x *Rsa) Decrypt(src []byte) ([]byte, error) {
+	if x.privateKey == nil {
+		return nil, ErrMissingPrivateKey
+	}
+	if !x.chunked {
+		return x.decryptBlock(src)
+	}
+
+	k := x.privateKey.Key.Size()
+	if len(src) == 0 || len(src)%k != 0 {
+		return nil, ErrInvalidCiphertextSize
+	}
+	var dst []byte
+	for i := 0; i < len(src); i += k {
+		block, err := x.decryptBlock(src[i : i+k])
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, block...)
+	}
+	return dst, nil
+}
+
+func (x *Rsa) decryptBlock(src []byte) ([]byte, error) {
 	switch x.scheme {
 	case PKCS1:
 		return rsa.DecryptPKCS1v15(rand.Reader, &x.privateKey.Key, src)
 	case OAEP:
-		stdHash, err := hash.GetStdCryptoHash(x.hash)
+		opts, err := x.oaepOptions()
 		if err != nil {
 			return nil, err
 		}
-		return (&x.privateKey.Key).Decrypt(rand.Reader, src, &rsa.OAEPOptions{Hash: stdHash})
+		return (&x.privateKey.Key).Decrypt(rand.Reader, src, opts)
+	default:
+		return nil, ErrInvalidEncryptionScheme
+	}
+}
+
+// DecryptSessionKey decrypts a symmetric key of keySize bytes transported with rsa.
+// It never reports padding errors: if src does not decrypt to a key of keySize bytes
+// a random key is returned instead, in constant time, so the result can't be used as a
+// Bleichenbacher padding oracle (RFC 3218 section 2.3.2). The caller must continue the
+// protocol with the returned key and let it fail at the symmetric layer.
+// Use at least 16 byte keys, smaller random keys can be brute-forced.
+func (x *Rsa) DecryptSessionKey(src []byte, keySize int) ([]byte, error) {
+	if x.privateKey == nil {
+		return nil, ErrMissingPrivateKey
+	}
+	if keySize <= 0 {
+		return nil, ErrInvalidSessionKeySize
+	}
+	// the fallback key must be unpredictable, so it always comes from crypto/rand
+	key := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	switch x.scheme {
+	case PKCS1:
+		//lint:ignore SA1019 PKCS1 key transport is still required by partners
+		if err := rsa.DecryptPKCS1v15SessionKey(nil, &x.privateKey.Key, src, key); err != nil {
+			// only returned for a ciphertext of the wrong size, which is public
+			return nil, err
+		}
+		return key, nil
+	case OAEP:
+		opts, err := x.oaepOptions()
+		if err != nil {
+			return nil, err
+		}
+		plain, err := (&x.privateKey.Key).Decrypt(rand.Reader, src, opts)
+		valid := subtle.ConstantTimeEq(int32(len(plain)), int32(keySize))
+		if err == nil && valid == 1 {
+			subtle.ConstantTimeCopy(1, key, plain)
+		}
+		return key, nil
 	default:
 		return nil, ErrInvalidEncryptionScheme
 	}
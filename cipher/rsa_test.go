@@ -1,7 +1,11 @@
 package cipher
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/priyanshujain/crypto/hash"
@@ -60,6 +64,30 @@ var rsaTestCases = []struct {
 		scheme: OAEP,
 		htype:  hash.SHA1,
 	},
+	{
+		name:   "OAEP-SHA224",
+		in:     "This is a PSS sign test",
+		scheme: OAEP,
+		htype:  hash.SHA224,
+	},
+	{
+		name:   "OAEP-SHA384",
+		in:     "This is a PSS sign test",
+		scheme: OAEP,
+		htype:  hash.SHA384,
+	},
+	{
+		name:   "OAEP-SHA512",
+		in:     "This is a PSS sign test",
+		scheme: OAEP,
+		htype:  hash.SHA512,
+	},
+	{
+		name:   "OAEP-SHA512/256",
+		in:     "This is a PSS sign test",
+		scheme: OAEP,
+		htype:  hash.SHA512_256,
+	},
 }
 
 func TestPKCS1RsaEncryption(t *testing.T) {
@@ -81,3 +109,275 @@ func TestPKCS1RsaEncryption(t *testing.T) {
 		})
 	}
 }
+
+func TestRsaEncryptorDecryptor(t *testing.T) {
+	rsaPrivateKey, _ := keystore.ParsePrivateKeyFromPem([]byte(privateKeyPem))
+	for _, test := range rsaTestCases {
+		t.Run(fmt.Sprintf("RSA-Encryptor: %s:", test.name), func(t *testing.T) {
+			encryptor, err := NewRsaEncryptor(rsaPrivateKey.PublicKey(), WithScheme(test.scheme), WithOAEPHash(test.htype))
+			if err != nil {
+				t.Fatalf("NewRsaEncryptor() error = %v", err)
+			}
+			decryptor, err := NewRsaDecryptor(rsaPrivateKey, WithScheme(test.scheme), WithOAEPHash(test.htype))
+			if err != nil {
+				t.Fatalf("NewRsaDecryptor() error = %v", err)
+			}
+			dst, err := encryptor.Encrypt([]byte(test.in))
+			if err != nil {
+				t.Fatalf("RSA-Encryption(%s) failed: %s", test.in, err)
+			}
+			decryptedSrc, err := decryptor.Decrypt(dst)
+			if err != nil {
+				t.Fatalf("RSA-Decryption(%s) failed: %s", test.in, err)
+			}
+			if string(decryptedSrc) != test.in {
+				t.Errorf("RSA-Decryption(%s) = %s, want %s", test.in, string(decryptedSrc), test.in)
+			}
+
+			if _, err := encryptor.Decrypt(dst); err != ErrMissingPrivateKey {
+				t.Errorf("Decrypt() error = %v, want %v", err, ErrMissingPrivateKey)
+			}
+			if _, err := decryptor.Encrypt([]byte(test.in)); err != ErrMissingPublicKey {
+				t.Errorf("Encrypt() error = %v, want %v", err, ErrMissingPublicKey)
+			}
+		})
+	}
+}
+
+func TestRsaEncryptorErrors(t *testing.T) {
+	rsaPrivateKey, _ := keystore.ParsePrivateKeyFromPem([]byte(privateKeyPem))
+	if _, err := NewRsaEncryptor(nil); err != ErrMissingPublicKey {
+		t.Errorf("NewRsaEncryptor(nil) error = %v, want %v", err, ErrMissingPublicKey)
+	}
+	if _, err := NewRsaDecryptor(nil); err != ErrMissingPrivateKey {
+		t.Errorf("NewRsaDecryptor(nil) error = %v, want %v", err, ErrMissingPrivateKey)
+	}
+	if _, err := NewRsaEncryptor(rsaPrivateKey.PublicKey(), WithScheme(0)); err != ErrInvalidEncryptionScheme {
+		t.Errorf("NewRsaEncryptor() error = %v, want %v", err, ErrInvalidEncryptionScheme)
+	}
+	if _, err := NewRsaDecryptor(rsaPrivateKey, WithOAEPHash(0)); err != hash.ErrInvalidHashType {
+		t.Errorf("NewRsaDecryptor() error = %v, want %v", err, hash.ErrInvalidHashType)
+	}
+}
+
+// Ciphertexts of "interop test" under the test key, produced with openssl 3.0 pkeyutl
+var rsaOAEPInteropTestCases = []struct {
+	name    string
+	opts    []RsaOption
+	payload string
+}{
+	{
+		// the parameters of Java's OAEPWithSHA-256AndMGF1Padding: OAEP SHA256, MGF1 SHA1
+		// openssl pkeyutl -encrypt -pkeyopt rsa_padding_mode:oaep -pkeyopt rsa_oaep_md:sha256 -pkeyopt rsa_mgf1_md:sha1
+		name:    "OpenSSL-OAEP-SHA256-MGF1-SHA1",
+		opts:    []RsaOption{WithOAEPHash(hash.SHA256), WithMGF1Hash(hash.SHA1)},
+		payload: "686222fc98af0555dcb2b8eb98fb7e8a4ee3ac1588117945dcacae949f76d0e64e18906976aa58b2009a5e5d4302d1ae8d531dae414a73394d12d38bb47602cde3de519769d38d9e89ec3019a9123770b3cf518561fbbc37abdd83fd146c5a49dd24d5e0cb91c06583532846afd59e68131e61975029914f20676e92121892cad5a3e7292a5a8f89ab755e523b7bb068011a96a6b320c26ef0d08ee40a013af9e824dc510002751b7ed3f06cbcfceccb5016baf1ab4e9763088a5af675233a732c1e8a83eb225f734ffcc666aa16a33e72051b1df1530e6660cf7ae483d98fe674a3e33f5a56a5f386cf1411e5a98d1d60c2c3c240fe868a3c37ec9b91e87b03",
+	},
+	{
+		// openssl defaults: OAEP SHA1, MGF1 SHA1
+		// openssl pkeyutl -encrypt -pkeyopt rsa_padding_mode:oaep
+		name:    "OpenSSL-OAEP-SHA1",
+		opts:    []RsaOption{WithOAEPHash(hash.SHA1)},
+		payload: "001dcc44ac55cc1022f09dd26505217fc6eb4dd0ca44d24e6a295612eb50ee04fcf0b15e920fe8187d2386222abf2756f185252264c74d895e90b94402f6633f5212cf0b799e9c18208737cacfadae8f716d2987080ce2b89d70fce3ac72812c3697d207b80c701522adadaf1dd773e3796a28fc2ce958c8cadbb4b200b6ab956f8763a00c0c2c81c3f5b12548befedef1473d920c266dc6145d6717a0c53277f87de588bc219388ee85cb14e22d157fd2a8e526f676a759427e69660bc6a6eb862b76c0d3f3267eace2be69199b1c9343a3812d5452f59c41fa838d94d777abbfac5af74b5522b6446c31ddc1c37f17ad80074c09c0a649f38e8d7b72cab76b",
+	},
+	{
+		// openssl pkeyutl -encrypt -pkeyopt rsa_padding_mode:oaep -pkeyopt rsa_oaep_md:sha256 -pkeyopt rsa_oaep_label:706172746e6572
+		name:    "OpenSSL-OAEP-SHA256-Label",
+		opts:    []RsaOption{WithOAEPHash(hash.SHA256), WithOAEPLabel([]byte("partner"))},
+		payload: "10165e4079cbfee7e0da78c4a8cf3b76609accd520ada9df7c06dc2e6ae04f330f1ec796b29a5136c56797c72878f69870933d85d6148607bbe64038a2aadcf1851e576b6524ac805f5d645a48a382661b720d04f58d5bf228deb3ae4458f57cb01c66ce30ccf45e84d230b2eb7fef035662e6b653f8fdf38eb5548d7986f20a6588b4965b462d05cf093ceee8ddfae5a640d08ce7dfb6e9ff5c011bfc4c0036957a099f7d370734f8ba8bdfd01de468054a74e7c457fd30a319ad2806da11f5d433e25a7ae1e864d999b9da9ed18ec7457ab5eeaafa34b723b94e990a6b87aa9dc19ae414b2f767167313d66908a8d5bac570f3c8b27136348bcda83f4e493d",
+	},
+}
+
+func TestRsaOAEPInterop(t *testing.T) {
+	rsaPrivateKey, _ := keystore.ParsePrivateKeyFromPem([]byte(privateKeyPem))
+	for _, test := range rsaOAEPInteropTestCases {
+		t.Run(fmt.Sprintf("RSA-OAEP-Interop: %s:", test.name), func(t *testing.T) {
+			decryptor, err := NewRsaDecryptor(rsaPrivateKey, test.opts...)
+			if err != nil {
+				t.Fatalf("NewRsaDecryptor() error = %v", err)
+			}
+			payload, _ := hex.DecodeString(test.payload)
+			dst, err := decryptor.Decrypt(payload)
+			if err != nil {
+				t.Fatalf("RSA-Decryption failed: %s", err)
+			}
+			if string(dst) != "interop test" {
+				t.Errorf("RSA-Decryption = %q, want %q", dst, "interop test")
+			}
+
+			// round trip through Encrypt with the same parameters
+			encryptor, _ := NewRsaEncryptor(rsaPrivateKey.PublicKey(), test.opts...)
+			payload, err = encryptor.Encrypt([]byte("interop test"))
+			if err != nil {
+				t.Fatalf("RSA-Encryption failed: %s", err)
+			}
+			if _, err := decryptor.Decrypt(payload); err != nil {
+				t.Errorf("RSA-Decryption failed: %s", err)
+			}
+		})
+	}
+}
+
+// testdata/oaep-java/ciphertext.hex is produced by Java's Cipher with
+// RSA/ECB/OAEPWithSHA-256AndMGF1Padding, see testdata/oaep-java/OaepFixture.java
+func TestRsaOAEPJavaFixture(t *testing.T) {
+	data, err := os.ReadFile("testdata/oaep-java/ciphertext.hex")
+	if errors.Is(err, os.ErrNotExist) {
+		t.Skip("testdata/oaep-java/ciphertext.hex missing, run OaepFixture.java with a JDK to create it")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPrivateKey, _ := keystore.ParsePrivateKeyFromPem([]byte(privateKeyPem))
+	decryptor, err := NewRsaDecryptor(rsaPrivateKey, WithOAEPHash(hash.SHA256), WithMGF1Hash(hash.SHA1))
+	if err != nil {
+		t.Fatalf("NewRsaDecryptor() error = %v", err)
+	}
+	payload, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := decryptor.Decrypt(payload)
+	if err != nil {
+		t.Fatalf("RSA-Decryption failed: %s", err)
+	}
+	if string(dst) != "interop test" {
+		t.Errorf("RSA-Decryption = %q, want %q", dst, "interop test")
+	}
+}
+
+func TestRsaOAEPLabelMismatch(t *testing.T) {
+	rsaPrivateKey, _ := keystore.ParsePrivateKeyFromPem([]byte(privateKeyPem))
+	encryptor, _ := NewRsaEncryptor(rsaPrivateKey.PublicKey(), WithOAEPLabel([]byte("a")))
+	decryptor, _ := NewRsaDecryptor(rsaPrivateKey, WithOAEPLabel([]byte("b")))
+	dst, err := encryptor.Encrypt([]byte("test"))
+	if err != nil {
+		t.Fatalf("RSA-Encryption failed: %s", err)
+	}
+	if _, err := decryptor.Decrypt(dst); err == nil {
+		t.Errorf("RSA-Decryption with a different label succeeded")
+	}
+}
+
+func TestRsaMaxPlaintextSize(t *testing.T) {
+	rsaPrivateKey, _ := keystore.ParsePrivateKeyFromPem([]byte(privateKeyPem))
+	k := rsaPrivateKey.Key.Size()
+	tests := []struct {
+		opts []RsaOption
+		size int
+	}{
+		{opts: []RsaOption{WithScheme(PKCS1)}, size: k - 11},
+		{opts: []RsaOption{WithScheme(OAEP), WithOAEPHash(hash.SHA1)}, size: k - 42},
+		{opts: []RsaOption{WithScheme(OAEP), WithOAEPHash(hash.SHA256)}, size: k - 66},
+	}
+	for _, test := range tests {
+		encryptor, _ := NewRsaEncryptor(rsaPrivateKey.PublicKey(), test.opts...)
+		size, err := encryptor.MaxPlaintextSize()
+		if err != nil {
+			t.Fatalf("MaxPlaintextSize() error = %v", err)
+		}
+		if size != test.size {
+			t.Errorf("MaxPlaintextSize() = %d, want %d", size, test.size)
+		}
+		if _, err := encryptor.Encrypt(make([]byte, size)); err != nil {
+			t.Errorf("Encrypt(%d bytes) error = %v", size, err)
+		}
+		if _, err := encryptor.Encrypt(make([]byte, size+1)); err != ErrPlaintextTooLong {
+			t.Errorf("Encrypt(%d bytes) error = %v, want %v", size+1, err, ErrPlaintextTooLong)
+		}
+	}
+}
+
+func TestRsaKeyTooSmall(t *testing.T) {
+	// a 1024-bit key leaves no room for OAEP with SHA512: 128 - 2*64 - 2 < 1
+	priv, pub, err := keystore.GenerateKeyPair(1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := []RsaOption{WithOAEPHash(hash.SHA512), WithChunking()}
+	if _, err := NewRsaEncryptor(pub, opts...); err != ErrKeyTooSmall {
+		t.Errorf("NewRsaEncryptor() error = %v, want %v", err, ErrKeyTooSmall)
+	}
+	if _, err := NewRsaDecryptor(priv, opts...); err != ErrKeyTooSmall {
+		t.Errorf("NewRsaDecryptor() error = %v, want %v", err, ErrKeyTooSmall)
+	}
+	x := &Rsa{publicKey: pub, scheme: OAEP, hash: hash.SHA512, chunked: true}
+	if _, err := x.MaxPlaintextSize(); err != ErrKeyTooSmall {
+		t.Errorf("MaxPlaintextSize() error = %v, want %v", err, ErrKeyTooSmall)
+	}
+	if _, err := x.Encrypt([]byte("data")); err != ErrKeyTooSmall {
+		t.Errorf("Encrypt() error = %v, want %v", err, ErrKeyTooSmall)
+	}
+	if _, err := NewRsaEncryptor(pub, WithOAEPHash(hash.SHA384)); err != nil {
+		t.Errorf("NewRsaEncryptor() with SHA384 error = %v", err)
+	}
+}
+
+func TestRsaChunking(t *testing.T) {
+	rsaPrivateKey, _ := keystore.ParsePrivateKeyFromPem([]byte(privateKeyPem))
+	k := rsaPrivateKey.Key.Size()
+	for _, scheme := range []RsaEncryptionScheme{PKCS1, OAEP} {
+		encryptor, _ := NewRsaEncryptor(rsaPrivateKey.PublicKey(), WithScheme(scheme), WithChunking())
+		decryptor, _ := NewRsaDecryptor(rsaPrivateKey, WithScheme(scheme), WithChunking())
+		size, _ := encryptor.MaxPlaintextSize()
+		for _, n := range []int{0, 1, size, size + 1, 3*size + 7} {
+			src := make([]byte, n)
+			for i := range src {
+				src[i] = byte(i)
+			}
+			dst, err := encryptor.Encrypt(src)
+			if err != nil {
+				t.Fatalf("Encrypt(%d bytes) error = %v", n, err)
+			}
+			chunks := (n + size - 1) / size
+			if chunks == 0 {
+				chunks = 1
+			}
+			if len(dst) != chunks*k {
+				t.Errorf("Encrypt(%d bytes) = %d bytes, want %d", n, len(dst), chunks*k)
+			}
+			result, err := decryptor.Decrypt(dst)
+			if err != nil {
+				t.Fatalf("Decrypt(%d bytes) error = %v", n, err)
+			}
+			if string(result) != string(src) {
+				t.Errorf("Decrypt(%d bytes) returned a different plaintext", n)
+			}
+		}
+		if _, err := decryptor.Decrypt(make([]byte, k+1)); err != ErrInvalidCiphertextSize {
+			t.Errorf("Decrypt() error = %v, want %v", err, ErrInvalidCiphertextSize)
+		}
+	}
+}
+
+func TestRsaDecryptSessionKey(t *testing.T) {
+	rsaPrivateKey, _ := keystore.ParsePrivateKeyFromPem([]byte(privateKeyPem))
+	sessionKey := []byte("0123456789abcdef0123456789abcdef")
+	for _, scheme := range []RsaEncryptionScheme{PKCS1, OAEP} {
+		encryptor, _ := NewRsaEncryptor(rsaPrivateKey.PublicKey(), WithScheme(scheme))
+		decryptor, _ := NewRsaDecryptor(rsaPrivateKey, WithScheme(scheme))
+		wrapped, err := encryptor.Encrypt(sessionKey)
+		if err != nil {
+			t.Fatalf("Encrypt() error = %v", err)
+		}
+
+		key, err := decryptor.DecryptSessionKey(wrapped, len(sessionKey))
+		if err != nil {
+			t.Fatalf("DecryptSessionKey() error = %v", err)
+		}
+		if string(key) != string(sessionKey) {
+			t.Errorf("DecryptSessionKey() = %x, want %x", key, sessionKey)
+		}
+
+		// wrong key size and tampered ciphertext must not be distinguishable by an error
+		key, err = decryptor.DecryptSessionKey(wrapped, 16)
+		if err != nil || len(key) != 16 {
+			t.Errorf("DecryptSessionKey(wrong size) = %x, %v", key, err)
+		}
+		wrapped[len(wrapped)-1] ^= 1
+		key, err = decryptor.DecryptSessionKey(wrapped, len(sessionKey))
+		if err != nil || len(key) != len(sessionKey) || string(key) == string(sessionKey) {
+			t.Errorf("DecryptSessionKey(tampered) = %x, %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+import java.nio.charset.StandardCharsets;
+import java.nio.file.Files;
+import java.nio.file.Path;
+import java.security.KeyFactory;
+import java.security.PublicKey;
+import java.security.spec.X509EncodedKeySpec;
+import java.util.Base64;
+import java.util.HexFormat;
+import javax.crypto.Cipher;
+
+// Encrypts "interop test" to the test key of rsa_test.go with the defaults of Java's
+// OAEPWithSHA-256AndMGF1Padding (OAEP SHA-256, MGF1 SHA-1). Run with JDK 17 or later
+// in this directory:
+//
+//   java OaepFixture.java > ciphertext.hex
+public class OaepFixture {
+    public static void main(String[] args) throws Exception {
+        String pem = Files.readString(Path.of("public.pem"))
+            .replaceAll("-----[A-Z ]+-----", "")
+            .replaceAll("\\s", "");
+        PublicKey key = KeyFactory.getInstance("RSA")
+            .generatePublic(new X509EncodedKeySpec(Base64.getDecoder().decode(pem)));
+        Cipher cipher = Cipher.getInstance("RSA/ECB/OAEPWithSHA-256AndMGF1Padding");
+        cipher.init(Cipher.ENCRYPT_MODE, key);
+        byte[] ciphertext = cipher.doFinal("interop test".getBytes(StandardCharsets.UTF_8));
+        System.out.println(HexFormat.of().formatHex(ciphertext));
+    }
+}
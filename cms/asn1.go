@@ -0,0 +1,123 @@
+package cms
+
+// ASN.1 structures of RFC 5652, RFC 5083, RFC 5084 and RFC 4055
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+
+	"github.com/priyanshujain/crypto/hash"
+)
+
+var (
+	oidData              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEnvelopedData     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	oidAuthEnvelopedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 23}
+
+	oidRsaEncryption = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidRsaesOaep     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 7}
+	oidMgf1          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 8}
+	oidPSpecified    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 9}
+
+	oidSha1       = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSha256     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSha384     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSha512     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidSha224     = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 4}
+	oidSha512_224 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 5}
+	oidSha512_256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 6}
+
+	oidAes128CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAes256CBC = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidAes128GCM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 6}
+	oidAes256GCM = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 46}
+)
+
+var contentEncryptionOids = map[ContentEncryption]asn1.ObjectIdentifier{
+	AES128CBC: oidAes128CBC,
+	AES256CBC: oidAes256CBC,
+	AES128GCM: oidAes128GCM,
+	AES256GCM: oidAes256GCM,
+}
+
+var hashOids = map[hash.HashType]asn1.ObjectIdentifier{
+	hash.SHA1:       oidSha1,
+	hash.SHA256:     oidSha256,
+	hash.SHA224:     oidSha224,
+	hash.SHA384:     oidSha384,
+	hash.SHA512:     oidSha512,
+	hash.SHA512_224: oidSha512_224,
+	hash.SHA512_256: oidSha512_256,
+}
+
+func contentEncryptionOf(oid asn1.ObjectIdentifier) (ContentEncryption, error) {
+	for enc, encOid := range contentEncryptionOids {
+		if oid.Equal(encOid) {
+			return enc, nil
+		}
+	}
+	return 0, ErrUnsupportedAlgorithm
+}
+
+func hashOf(oid asn1.ObjectIdentifier) (hash.HashType, error) {
+	for htype, hashOid := range hashOids {
+		if oid.Equal(hashOid) {
+			return htype, nil
+		}
+	}
+	return 0, ErrUnsupportedAlgorithm
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type envelopedData struct {
+	Version              int
+	OriginatorInfo       asn1.RawValue   `asn1:"optional,tag:0"`
+	RecipientInfos       []asn1.RawValue `asn1:"set"`
+	EncryptedContentInfo encryptedContentInfo
+	UnprotectedAttrs     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type authEnvelopedData struct {
+	Version                  int
+	OriginatorInfo           asn1.RawValue   `asn1:"optional,tag:0"`
+	RecipientInfos           []asn1.RawValue `asn1:"set"`
+	AuthEncryptedContentInfo encryptedContentInfo
+	AuthAttrs                asn1.RawValue `asn1:"optional,tag:1"`
+	Mac                      []byte
+	UnauthAttrs              asn1.RawValue `asn1:"optional,tag:2"`
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// keyTransRecipientInfo, Rid is an issuerAndSerialNumber or a [0] subjectKeyIdentifier
+type keyTransRecipientInfo struct {
+	Version                int
+	Rid                    asn1.RawValue
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type gcmParameters struct {
+	Nonce  []byte
+	ICVLen int `asn1:"default:12"`
+}
+
+type rsaesOaepParams struct {
+	Hash    pkix.AlgorithmIdentifier `asn1:"optional,explicit,tag:0"`
+	MGF     pkix.AlgorithmIdentifier `asn1:"optional,explicit,tag:1"`
+	PSource pkix.AlgorithmIdentifier `asn1:"optional,explicit,tag:2"`
+}
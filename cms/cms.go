@@ -0,0 +1,592 @@
+// cms package implements enveloped data of the Cryptographic Message Syntax
+// https://datatracker.ietf.org/doc/html/rfc5652
+// The content is encrypted with AES-CBC into EnvelopedData or with AES-GCM into
+// AuthEnvelopedData (https://datatracker.ietf.org/doc/html/rfc5083), and the content
+// encryption key is transported to every recipient certificate with RSA PKCS1 or OAEP.
+// Messages are DER encoded, as produced by openssl cms -encrypt -outform DER.
+package cms
+
+import (
+	"bytes"
+	"crypto/aes"
+	gocipher "crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/priyanshujain/crypto/cipher"
+	"github.com/priyanshujain/crypto/hash"
+	"github.com/priyanshujain/crypto/keystore"
+)
+
+type ContentEncryption uint
+
+// content encryption algorithms (RFC 3565 and RFC 5084)
+const (
+	AES128CBC ContentEncryption = 1 + iota
+	AES256CBC
+	AES128GCM // AuthEnvelopedData
+	AES256GCM // AuthEnvelopedData
+)
+
+type RecipientIdentifier uint
+
+// ways to identify the recipient certificate (RFC 5652 section 6.2.1)
+const (
+	IssuerAndSerialNumber RecipientIdentifier = 1 + iota
+	SubjectKeyIdentifier
+)
+
+// errors
+var (
+	// ErrUnsupportedAlgorithm indicates an algorithm or content type this package can't process
+	ErrUnsupportedAlgorithm = errors.New("cms: unsupported algorithm")
+
+	// ErrNoRecipients indicates Encrypt without recipients
+	ErrNoRecipients = errors.New("cms: no recipients")
+
+	// ErrInvalidCertificate indicates a recipient certificate without an rsa public key
+	ErrInvalidCertificate = errors.New("cms: recipient certificate has no rsa public key")
+
+	// ErrMissingSubjectKeyId indicates a recipient certificate without the subject key identifier extension
+	ErrMissingSubjectKeyId = errors.New("cms: recipient certificate has no subject key identifier")
+
+	// ErrRecipientNotFound indicates a message which is not encrypted to the certificate
+	ErrRecipientNotFound = errors.New("cms: message has no recipient info for certificate")
+
+	// ErrInvalidMessage indicates a malformed message
+	ErrInvalidMessage = errors.New("cms: invalid message")
+
+	// ErrDecryption indicates that the content could not be decrypted
+	ErrDecryption = errors.New("cms: decryption failed")
+)
+
+type config struct {
+	encryption ContentEncryption
+	identifier RecipientIdentifier
+	scheme     cipher.RsaEncryptionScheme
+	oaepHash   hash.HashType
+}
+
+// Option configures Encrypt
+type Option func(*config)
+
+// WithContentEncryption sets the content encryption algorithm, the default is AES256CBC
+func WithContentEncryption(enc ContentEncryption) Option {
+	return func(c *config) {
+		c.encryption = enc
+	}
+}
+
+// WithSubjectKeyIdentifier identifies recipients by the subject key identifier of their
+// certificate instead of its issuer and serial number, like openssl cms -keyid
+func WithSubjectKeyIdentifier() Option {
+	return func(c *config) {
+		c.identifier = SubjectKeyIdentifier
+	}
+}
+
+// WithOAEP transports the content encryption key with RSAES-OAEP and MGF1 using htype
+// instead of PKCS1, like openssl cms -keyopt rsa_padding_mode:oaep
+func WithOAEP(htype hash.HashType) Option {
+	return func(c *config) {
+		c.scheme = cipher.OAEP
+		c.oaepHash = htype
+	}
+}
+
+// EnvelopedData is a parsed EnvelopedData or AuthEnvelopedData message
+type EnvelopedData struct {
+	ContentEncryption ContentEncryption
+	Recipients        []RecipientInfo
+
+	params           []byte // IV for CBC, nonce for GCM
+	tagSize          int
+	encryptedContent []byte
+	aad              []byte
+	mac              []byte
+}
+
+// RecipientInfo is a key transport recipient of a message
+type RecipientInfo struct {
+	Issuer       []byte // DER encoded issuer name, set with SerialNumber
+	SerialNumber *big.Int
+	SubjectKeyId []byte
+	Scheme       cipher.RsaEncryptionScheme
+	OAEPHash     hash.HashType
+	MGF1Hash     hash.HashType
+	EncryptedKey []byte
+
+	label []byte
+}
+
+// Matches reports whether the recipient info identifies cert
+func (r *RecipientInfo) Matches(cert *x509.Certificate) bool {
+	if r.SubjectKeyId != nil {
+		return len(cert.SubjectKeyId) > 0 && bytes.Equal(r.SubjectKeyId, cert.SubjectKeyId)
+	}
+	return r.SerialNumber != nil && r.SerialNumber.Cmp(cert.SerialNumber) == 0 && bytes.Equal(r.Issuer, cert.RawIssuer)
+}
+
+func contentKeySize(enc ContentEncryption) (int, error) {
+	switch enc {
+	case AES128CBC, AES128GCM:
+		return 16, nil
+	case AES256CBC, AES256GCM:
+		return 32, nil
+	default:
+		return 0, ErrUnsupportedAlgorithm
+	}
+}
+
+func randomBytes(size int) ([]byte, error) {
+	b := make([]byte, size)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Encrypt encrypts content to the rsa public keys of the recipient certificates and
+// returns the DER encoded ContentInfo
+func Encrypt(content []byte, recipients []*x509.Certificate, opts ...Option) ([]byte, error) {
+	c := &config{encryption: AES256CBC, identifier: IssuerAndSerialNumber, scheme: cipher.PKCS1}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if len(recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+	keySize, err := contentKeySize(c.encryption)
+	if err != nil {
+		return nil, err
+	}
+	key, err := randomBytes(keySize)
+	if err != nil {
+		return nil, err
+	}
+
+	recipientInfos := make([]asn1.RawValue, 0, len(recipients))
+	for _, cert := range recipients {
+		ri, err := c.recipientInfo(cert, key)
+		if err != nil {
+			return nil, err
+		}
+		recipientInfos = append(recipientInfos, asn1.RawValue{FullBytes: ri})
+	}
+
+	var contentType asn1.ObjectIdentifier
+	var inner interface{}
+	switch c.encryption {
+	case AES128CBC, AES256CBC:
+		eci, err := encryptCBC(c.encryption, key, content)
+		if err != nil {
+			return nil, err
+		}
+		version := 0
+		if c.identifier == SubjectKeyIdentifier {
+			version = 2
+		}
+		contentType = oidEnvelopedData
+		inner = envelopedData{Version: version, RecipientInfos: recipientInfos, EncryptedContentInfo: *eci}
+	default:
+		eci, mac, err := encryptGCM(c.encryption, key, content)
+		if err != nil {
+			return nil, err
+		}
+		contentType = oidAuthEnvelopedData
+		inner = authEnvelopedData{RecipientInfos: recipientInfos, AuthEncryptedContentInfo: *eci, Mac: mac}
+	}
+	innerBytes, err := asn1.Marshal(inner)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: contentType,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: innerBytes},
+	})
+}
+
+// recipientInfo returns the DER encoded KeyTransRecipientInfo of cert
+func (c *config) recipientInfo(cert *x509.Certificate, key []byte) ([]byte, error) {
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, ErrInvalidCertificate
+	}
+	ri := keyTransRecipientInfo{}
+	switch c.identifier {
+	case IssuerAndSerialNumber:
+		rid, err := asn1.Marshal(issuerAndSerialNumber{
+			Issuer:       asn1.RawValue{FullBytes: cert.RawIssuer},
+			SerialNumber: cert.SerialNumber,
+		})
+		if err != nil {
+			return nil, err
+		}
+		ri.Rid = asn1.RawValue{FullBytes: rid}
+	case SubjectKeyIdentifier:
+		if len(cert.SubjectKeyId) == 0 {
+			return nil, ErrMissingSubjectKeyId
+		}
+		ri.Version = 2
+		ri.Rid = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: cert.SubjectKeyId}
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	rsaOpts := []cipher.RsaOption{cipher.WithScheme(c.scheme)}
+	switch c.scheme {
+	case cipher.PKCS1:
+		ri.KeyEncryptionAlgorithm = pkix.AlgorithmIdentifier{Algorithm: oidRsaEncryption, Parameters: asn1.NullRawValue}
+	case cipher.OAEP:
+		params, err := oaepParameters(c.oaepHash)
+		if err != nil {
+			return nil, err
+		}
+		ri.KeyEncryptionAlgorithm = pkix.AlgorithmIdentifier{Algorithm: oidRsaesOaep, Parameters: asn1.RawValue{FullBytes: params}}
+		rsaOpts = append(rsaOpts, cipher.WithOAEPHash(c.oaepHash))
+	default:
+		return nil, cipher.ErrInvalidEncryptionScheme
+	}
+	encryptor, err := cipher.NewRsaEncryptor(&keystore.PublicKey{Key: *pub}, rsaOpts...)
+	if err != nil {
+		return nil, err
+	}
+	ri.EncryptedKey, err = encryptor.Encrypt(key)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ri)
+}
+
+// oaepParameters returns the DER encoded RSAES-OAEP-params, SHA1 is the default and is omitted
+func oaepParameters(htype hash.HashType) ([]byte, error) {
+	hashOid, ok := hashOids[htype]
+	if !ok {
+		return nil, hash.ErrInvalidHashType
+	}
+	var params rsaesOaepParams
+	if htype != hash.SHA1 {
+		hashAlgorithm := pkix.AlgorithmIdentifier{Algorithm: hashOid}
+		mgfParams, err := asn1.Marshal(hashAlgorithm)
+		if err != nil {
+			return nil, err
+		}
+		params.Hash = hashAlgorithm
+		params.MGF = pkix.AlgorithmIdentifier{Algorithm: oidMgf1, Parameters: asn1.RawValue{FullBytes: mgfParams}}
+	}
+	return asn1.Marshal(params)
+}
+
+func encryptCBC(enc ContentEncryption, key, content []byte) (*encryptedContentInfo, error) {
+	iv, err := randomBytes(aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	padded, err := cipher.AddPadding(content, cipher.PKCS7)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext := make([]byte, len(padded))
+	gocipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+
+	params, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedContentInfo{
+		ContentType: oidData,
+		ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  contentEncryptionOids[enc],
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		EncryptedContent: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: ciphertext},
+	}, nil
+}
+
+func encryptGCM(enc ContentEncryption, key, content []byte) (*encryptedContentInfo, []byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := gocipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce, err := randomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, nil, err
+	}
+	// authAttrs are absent so the additional authenticated data is empty
+	sealed := aead.Seal(nil, nonce, content, nil)
+	split := len(sealed) - aead.Overhead()
+
+	params, err := asn1.Marshal(gcmParameters{Nonce: nonce, ICVLen: aead.Overhead()})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &encryptedContentInfo{
+		ContentType: oidData,
+		ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  contentEncryptionOids[enc],
+			Parameters: asn1.RawValue{FullBytes: params},
+		},
+		EncryptedContent: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: sealed[:split]},
+	}, sealed[split:], nil
+}
+
+// ConvertToPem converts a DER encoded message to pem format, like openssl cms -outform PEM
+func ConvertToPem(der []byte) []byte {
+	b := pem.EncodeToMemory(&pem.Block{Type: "CMS", Bytes: der})
+	// strip last newline
+	return b[:len(b)-1]
+}
+
+// Parse parses a DER or pem encoded EnvelopedData or AuthEnvelopedData message
+func Parse(data []byte) (*EnvelopedData, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	var ci contentInfo
+	if rest, err := asn1.Unmarshal(data, &ci); err != nil || len(rest) > 0 {
+		return nil, invalidMessage(err)
+	}
+
+	var recipientInfos []asn1.RawValue
+	var eci encryptedContentInfo
+	e := &EnvelopedData{}
+	switch {
+	case ci.ContentType.Equal(oidEnvelopedData):
+		var ed envelopedData
+		if _, err := asn1.Unmarshal(ci.Content.Bytes, &ed); err != nil {
+			return nil, invalidMessage(err)
+		}
+		recipientInfos, eci = ed.RecipientInfos, ed.EncryptedContentInfo
+	case ci.ContentType.Equal(oidAuthEnvelopedData):
+		var aed authEnvelopedData
+		if _, err := asn1.Unmarshal(ci.Content.Bytes, &aed); err != nil {
+			return nil, invalidMessage(err)
+		}
+		recipientInfos, eci, e.mac = aed.RecipientInfos, aed.AuthEncryptedContentInfo, aed.Mac
+		if len(aed.AuthAttrs.FullBytes) > 0 {
+			// authenticated with the EXPLICIT SET OF tag (RFC 5083 section 2.2)
+			e.aad = append([]byte{}, aed.AuthAttrs.FullBytes...)
+			e.aad[0] = 0x31
+		}
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	var err error
+	e.ContentEncryption, err = contentEncryptionOf(eci.ContentEncryptionAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	gcm := e.ContentEncryption == AES128GCM || e.ContentEncryption == AES256GCM
+	if gcm != ci.ContentType.Equal(oidAuthEnvelopedData) {
+		return nil, ErrInvalidMessage
+	}
+	params := eci.ContentEncryptionAlgorithm.Parameters.FullBytes
+	if gcm {
+		var p gcmParameters
+		if _, err := asn1.Unmarshal(params, &p); err != nil {
+			return nil, invalidMessage(err)
+		}
+		e.params, e.tagSize = p.Nonce, p.ICVLen
+	} else if _, err := asn1.Unmarshal(params, &e.params); err != nil {
+		return nil, invalidMessage(err)
+	}
+	e.encryptedContent, err = octetString(eci.EncryptedContent)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, raw := range recipientInfos {
+		// other recipient info types are context specific choices
+		if raw.Class != asn1.ClassUniversal || raw.Tag != asn1.TagSequence {
+			continue
+		}
+		ri, err := parseRecipientInfo(raw.FullBytes)
+		if err != nil {
+			return nil, err
+		}
+		e.Recipients = append(e.Recipients, *ri)
+	}
+	return e, nil
+}
+
+func invalidMessage(err error) error {
+	if err == nil {
+		return ErrInvalidMessage
+	}
+	return fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+}
+
+// octetString returns the content of an implicitly tagged OCTET STRING,
+// a constructed one is the concatenation of its segments
+func octetString(raw asn1.RawValue) ([]byte, error) {
+	if !raw.IsCompound {
+		return raw.Bytes, nil
+	}
+	var dst []byte
+	rest := raw.Bytes
+	for len(rest) > 0 {
+		var segment []byte
+		var err error
+		rest, err = asn1.Unmarshal(rest, &segment)
+		if err != nil {
+			return nil, invalidMessage(err)
+		}
+		dst = append(dst, segment...)
+	}
+	return dst, nil
+}
+
+func parseRecipientInfo(der []byte) (*RecipientInfo, error) {
+	var ktri keyTransRecipientInfo
+	if _, err := asn1.Unmarshal(der, &ktri); err != nil {
+		return nil, invalidMessage(err)
+	}
+	ri := &RecipientInfo{EncryptedKey: ktri.EncryptedKey}
+	switch {
+	case ktri.Rid.Class == asn1.ClassContextSpecific && ktri.Rid.Tag == 0:
+		ri.SubjectKeyId = ktri.Rid.Bytes
+	case ktri.Rid.Class == asn1.ClassUniversal && ktri.Rid.Tag == asn1.TagSequence:
+		var ias issuerAndSerialNumber
+		if _, err := asn1.Unmarshal(ktri.Rid.FullBytes, &ias); err != nil {
+			return nil, invalidMessage(err)
+		}
+		ri.Issuer, ri.SerialNumber = ias.Issuer.FullBytes, ias.SerialNumber
+	default:
+		return nil, ErrInvalidMessage
+	}
+
+	alg := ktri.KeyEncryptionAlgorithm
+	switch {
+	case alg.Algorithm.Equal(oidRsaEncryption):
+		ri.Scheme = cipher.PKCS1
+	case alg.Algorithm.Equal(oidRsaesOaep):
+		ri.Scheme, ri.OAEPHash, ri.MGF1Hash = cipher.OAEP, hash.SHA1, hash.SHA1
+		if len(alg.Parameters.FullBytes) == 0 {
+			break
+		}
+		var params rsaesOaepParams
+		if _, err := asn1.Unmarshal(alg.Parameters.FullBytes, &params); err != nil {
+			return nil, invalidMessage(err)
+		}
+		if params.Hash.Algorithm != nil {
+			htype, err := hashOf(params.Hash.Algorithm)
+			if err != nil {
+				return nil, err
+			}
+			ri.OAEPHash = htype
+		}
+		if params.MGF.Algorithm != nil {
+			var mgfHash pkix.AlgorithmIdentifier
+			if !params.MGF.Algorithm.Equal(oidMgf1) {
+				return nil, ErrUnsupportedAlgorithm
+			}
+			if _, err := asn1.Unmarshal(params.MGF.Parameters.FullBytes, &mgfHash); err != nil {
+				return nil, invalidMessage(err)
+			}
+			htype, err := hashOf(mgfHash.Algorithm)
+			if err != nil {
+				return nil, err
+			}
+			ri.MGF1Hash = htype
+		}
+		if params.PSource.Algorithm != nil {
+			if !params.PSource.Algorithm.Equal(oidPSpecified) {
+				return nil, ErrUnsupportedAlgorithm
+			}
+			if _, err := asn1.Unmarshal(params.PSource.Parameters.FullBytes, &ri.label); err != nil {
+				return nil, invalidMessage(err)
+			}
+		}
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+	return ri, nil
+}
+
+// Decrypt decrypts the content with the private key of the recipient certificate
+func (e *EnvelopedData) Decrypt(cert *x509.Certificate, key *keystore.PrivateKey) ([]byte, error) {
+	for i := range e.Recipients {
+		if e.Recipients[i].Matches(cert) {
+			return e.decrypt(&e.Recipients[i], key)
+		}
+	}
+	return nil, ErrRecipientNotFound
+}
+
+func (e *EnvelopedData) decrypt(ri *RecipientInfo, key *keystore.PrivateKey) ([]byte, error) {
+	keySize, err := contentKeySize(e.ContentEncryption)
+	if err != nil {
+		return nil, err
+	}
+	rsaOpts := []cipher.RsaOption{cipher.WithScheme(ri.Scheme)}
+	if ri.Scheme == cipher.OAEP {
+		rsaOpts = append(rsaOpts, cipher.WithOAEPHash(ri.OAEPHash), cipher.WithMGF1Hash(ri.MGF1Hash), cipher.WithOAEPLabel(ri.label))
+	}
+	decryptor, err := cipher.NewRsaDecryptor(key, rsaOpts...)
+	if err != nil {
+		return nil, err
+	}
+	// a wrong key transport yields a random content key and fails below
+	contentKey, err := decryptor.DecryptSessionKey(ri.EncryptedKey, keySize)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.ContentEncryption {
+	case AES128CBC, AES256CBC:
+		if len(e.params) != aes.BlockSize || len(e.encryptedContent) == 0 || len(e.encryptedContent)%aes.BlockSize != 0 {
+			return nil, ErrInvalidMessage
+		}
+		dst := make([]byte, len(e.encryptedContent))
+		gocipher.NewCBCDecrypter(block, e.params).CryptBlocks(dst, e.encryptedContent)
+		dst, err = cipher.TrimPadding(dst, cipher.PKCS7)
+		if err != nil {
+			return nil, ErrDecryption
+		}
+		return dst, nil
+	default:
+		if len(e.params) != 12 || len(e.mac) != e.tagSize {
+			return nil, ErrInvalidMessage
+		}
+		aead, err := gocipher.NewGCMWithTagSize(block, e.tagSize)
+		if err != nil {
+			return nil, invalidMessage(err)
+		}
+		sealed := append(append([]byte{}, e.encryptedContent...), e.mac...)
+		dst, err := aead.Open(nil, e.params, sealed, e.aad)
+		if err != nil {
+			return nil, ErrDecryption
+		}
+		return dst, nil
+	}
+}
+
+// Decrypt parses a message and decrypts it with the private key of the recipient certificate
+func Decrypt(data []byte, cert *x509.Certificate, key *keystore.PrivateKey) ([]byte, error) {
+	e, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return e.Decrypt(cert, key)
+}
@@ -0,0 +1,161 @@
+package hash
+
+// content-defined chunking with FastCDC
+// https://www.usenix.org/conference/atc16/technical-sessions/presentation/xia
+// A gear rolling hash over the last 64 bytes picks chunk boundaries from the content,
+// so an insertion or deletion only changes the chunks around it and the others deduplicate.
+// Normalized chunking uses a stricter mask before the average size and a looser one after it.
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/bits"
+)
+
+const (
+	defaultChunkAvgSize = 64 * 1024
+	minChunkSize        = 64
+	maxChunkSize        = 1 << 30
+)
+
+// errors
+var (
+	// ErrInvalidChunkSizes indicates chunk sizes which are not min <= avg <= max in the allowed range
+	ErrInvalidChunkSizes = errors.New("invalid chunk sizes")
+)
+
+// gearTable maps bytes to random values, derived from SHA-256 so chunk boundaries never change
+var gearTable = func() (table [256]uint64) {
+	for i := range table {
+		sum := sha256.Sum256([]byte{byte(i)})
+		table[i] = binary.BigEndian.Uint64(sum[:8])
+	}
+	return table
+}()
+
+// Chunk is a piece of the input with its digest
+type Chunk struct {
+	Offset int64
+	Data   []byte
+	Digest Digest
+}
+
+// Chunker splits a reader into content-defined chunks
+type Chunker struct {
+	r     io.Reader
+	htype HashType
+
+	minSize, avgSize, maxSize int
+	maskS, maskL              uint64 // masks before and after the average size
+
+	buf        []byte
+	start, end int // unread data in buf
+	offset     int64
+	eof        bool
+}
+
+// ChunkerOption configures a Chunker created by NewChunker
+type ChunkerOption func(*Chunker)
+
+// WithChunkSizes sets the minimum, average and maximum chunk sizes in bytes,
+// the default is 16 KiB, 64 KiB and 256 KiB
+func WithChunkSizes(min, avg, max int) ChunkerOption {
+	return func(c *Chunker) {
+		c.minSize, c.avgSize, c.maxSize = min, avg, max
+	}
+}
+
+// NewChunker returns a Chunker which reads from r and hashes every chunk with htype
+func NewChunker(r io.Reader, htype HashType, opts ...ChunkerOption) (*Chunker, error) {
+	if _, err := New(htype); err != nil {
+		return nil, err
+	}
+	c := &Chunker{
+		r:       r,
+		htype:   htype,
+		minSize: defaultChunkAvgSize / 4,
+		avgSize: defaultChunkAvgSize,
+		maxSize: defaultChunkAvgSize * 4,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.minSize < minChunkSize || c.minSize > c.avgSize || c.avgSize > c.maxSize || c.maxSize > maxChunkSize {
+		return nil, ErrInvalidChunkSizes
+	}
+	// normalization level 2, the masks are on the high bits which depend on the last 64 bytes
+	level := bits.Len(uint(c.avgSize)) - 1
+	c.maskS = ^uint64(0) << (64 - (level + 2))
+	c.maskL = ^uint64(0) << (64 - max(level-2, 1))
+	c.buf = make([]byte, c.maxSize)
+	return c, nil
+}
+
+// Next returns the next chunk, or io.EOF after the last one
+func (c *Chunker) Next() (Chunk, error) {
+	if err := c.fill(); err != nil {
+		return Chunk{}, err
+	}
+	if c.start == c.end {
+		return Chunk{}, io.EOF
+	}
+	n := c.cut(c.buf[c.start:c.end])
+	data := make([]byte, n)
+	copy(data, c.buf[c.start:c.start+n])
+	digest, err := Hash(c.htype, data)
+	if err != nil {
+		return Chunk{}, err
+	}
+	chunk := Chunk{Offset: c.offset, Data: data, Digest: Digest{Type: c.htype, Sum: digest}}
+	c.start += n
+	c.offset += int64(n)
+	return chunk, nil
+}
+
+// fill reads until the buffer holds a maximum sized chunk or the reader ends
+func (c *Chunker) fill() error {
+	if c.eof || c.end-c.start >= c.maxSize {
+		return nil
+	}
+	c.end = copy(c.buf, c.buf[c.start:c.end])
+	c.start = 0
+	for c.end < len(c.buf) {
+		n, err := c.r.Read(c.buf[c.end:])
+		c.end += n
+		if err == io.EOF {
+			c.eof = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cut returns the size of the chunk at the start of src
+func (c *Chunker) cut(src []byte) int {
+	n := len(src)
+	if n <= c.minSize {
+		return n
+	}
+	n = min(n, c.maxSize)
+	normal := min(n, c.avgSize)
+	var fp uint64
+	i := c.minSize
+	for ; i < normal; i++ {
+		fp = (fp << 1) + gearTable[src[i]]
+		if fp&c.maskS == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		fp = (fp << 1) + gearTable[src[i]]
+		if fp&c.maskL == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
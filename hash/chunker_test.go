@@ -0,0 +1,134 @@
+package hash
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"testing"
+	"testing/iotest"
+)
+
+func randomBytes(n int, seed uint64) []byte {
+	r := rand.New(rand.NewPCG(seed, seed))
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(r.Uint32())
+	}
+	return b
+}
+
+func readChunks(t *testing.T, r io.Reader, opts ...ChunkerOption) []Chunk {
+	c, err := NewChunker(r, SHA256, opts...)
+	if err != nil {
+		t.Fatalf("NewChunker() error = %v", err)
+	}
+	var chunks []Chunk
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			return chunks
+		}
+		if err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+func TestChunker(t *testing.T) {
+	data := randomBytes(4<<20, 1)
+	chunks := readChunks(t, bytes.NewReader(data))
+
+	var joined []byte
+	for i, chunk := range chunks {
+		if chunk.Offset != int64(len(joined)) {
+			t.Errorf("chunk %d Offset = %d, want %d", i, chunk.Offset, len(joined))
+		}
+		if len(chunk.Data) > 256*1024 || (len(chunk.Data) < 16*1024 && i != len(chunks)-1) {
+			t.Errorf("chunk %d size = %d, outside of the sizes", i, len(chunk.Data))
+		}
+		d, _ := HashReader(SHA256, bytes.NewReader(chunk.Data))
+		if !chunk.Digest.Equal(d) {
+			t.Errorf("chunk %d Digest = %s, want %s", i, chunk.Digest, d)
+		}
+		joined = append(joined, chunk.Data...)
+	}
+	if !bytes.Equal(joined, data) {
+		t.Fatalf("chunks do not add up to the input")
+	}
+	// about 64 KiB on average
+	if avg := len(data) / len(chunks); avg < 32*1024 || avg > 128*1024 {
+		t.Errorf("average chunk size = %d", avg)
+	}
+
+	// boundaries depend on the content only, not on how it is read
+	oneByte := readChunks(t, iotest.OneByteReader(bytes.NewReader(data)))
+	if len(oneByte) != len(chunks) {
+		t.Fatalf("chunks with one byte reads = %d, want %d", len(oneByte), len(chunks))
+	}
+	for i := range chunks {
+		if !oneByte[i].Digest.Equal(chunks[i].Digest) {
+			t.Errorf("chunk %d differs with one byte reads", i)
+		}
+	}
+}
+
+func TestChunkerDeduplication(t *testing.T) {
+	opts := WithChunkSizes(1024, 4096, 16384)
+	data := randomBytes(1<<20, 2)
+	edited := append(append(append([]byte{}, data[:300000]...), []byte("inserted bytes")...), data[300000:]...)
+
+	seen := map[string]bool{}
+	original := readChunks(t, bytes.NewReader(data), opts)
+	for _, chunk := range original {
+		seen[chunk.Digest.Hex()] = true
+	}
+	shared := 0
+	after := readChunks(t, bytes.NewReader(edited), opts)
+	for _, chunk := range after {
+		if seen[chunk.Digest.Hex()] {
+			shared++
+		}
+	}
+	// only the chunks around the insertion change
+	if shared < len(after)-3 {
+		t.Errorf("%d of %d chunks shared after an insertion", shared, len(after))
+	}
+}
+
+func TestChunkerSmallInput(t *testing.T) {
+	if chunks := readChunks(t, bytes.NewReader(nil)); len(chunks) != 0 {
+		t.Errorf("chunks of empty input = %d, want 0", len(chunks))
+	}
+	chunks := readChunks(t, bytes.NewReader([]byte("tiny")))
+	if len(chunks) != 1 || string(chunks[0].Data) != "tiny" {
+		t.Errorf("chunks of a tiny input = %+v", chunks)
+	}
+	// without boundaries in the content every chunk has the maximum size
+	chunks = readChunks(t, bytes.NewReader(make([]byte, 10000)), WithChunkSizes(64, 256, 1024))
+	if len(chunks) != 10 || len(chunks[0].Data) != 1024 || len(chunks[9].Data) != 784 {
+		t.Errorf("chunks of zeros = %d", len(chunks))
+	}
+}
+
+func TestChunkerErrors(t *testing.T) {
+	for _, sizes := range [][3]int{
+		{0, 64, 128},
+		{1024, 512, 2048},
+		{1024, 4096, 2048},
+		{1024, 4096, 1 << 31},
+	} {
+		if _, err := NewChunker(nil, SHA256, WithChunkSizes(sizes[0], sizes[1], sizes[2])); err != ErrInvalidChunkSizes {
+			t.Errorf("NewChunker(%v) error = %v, want %v", sizes, err, ErrInvalidChunkSizes)
+		}
+	}
+	if _, err := NewChunker(nil, 0); err != ErrInvalidHashType {
+		t.Errorf("NewChunker() error = %v, want %v", err, ErrInvalidHashType)
+	}
+	readErr := errors.New("read failed")
+	c, _ := NewChunker(iotest.ErrReader(readErr), SHA256)
+	if _, err := c.Next(); err != readErr {
+		t.Errorf("Next() error = %v, want %v", err, readErr)
+	}
+}
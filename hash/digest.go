@@ -0,0 +1,81 @@
+package hash
+
+// streaming and incremental hashing
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+)
+
+// Digest is the output of a hash algorithm together with the algorithm
+type Digest struct {
+	Type HashType
+	Sum  []byte
+}
+
+// Hex returns the digest in hex
+func (d Digest) Hex() string {
+	return hex.EncodeToString(d.Sum)
+}
+
+// Base64 returns the digest in standard base64
+func (d Digest) Base64() string {
+	return base64.StdEncoding.EncodeToString(d.Sum)
+}
+
+// Hasher hashes data written to it incrementally
+type Hasher struct {
+	htype HashType
+	h     hash.Hash
+}
+
+var _ io.Writer = (*Hasher)(nil)
+
+// NewHasher returns a Hasher for a given hash algorithm
+func NewHasher(htype HashType) (*Hasher, error) {
+	h, err := New(htype)
+	if err != nil {
+		return nil, err
+	}
+	return &Hasher{htype: htype, h: h}, nil
+}
+
+// Write adds more data to the hash, it never returns an error
+func (x *Hasher) Write(p []byte) (int, error) {
+	return x.h.Write(p)
+}
+
+// Sum returns the digest of the data written so far, more data can still be written
+func (x *Hasher) Sum() Digest {
+	return Digest{Type: x.htype, Sum: x.h.Sum(nil)}
+}
+
+// Reset discards the data written so far
+func (x *Hasher) Reset() {
+	x.h.Reset()
+}
+
+// HashReader hashes everything read from r until EOF without buffering it
+func HashReader(htype HashType, r io.Reader) (Digest, error) {
+	x, err := NewHasher(htype)
+	if err != nil {
+		return Digest{}, err
+	}
+	if _, err := io.Copy(x, r); err != nil {
+		return Digest{}, err
+	}
+	return x.Sum(), nil
+}
+
+// HashFile hashes the content of the file at path
+func HashFile(htype HashType, path string) (Digest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Digest{}, err
+	}
+	defer f.Close()
+	return HashReader(htype, f)
+}
@@ -0,0 +1,89 @@
+package hash
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHashReader(t *testing.T) {
+	for _, test := range hashTestCases {
+		t.Run(fmt.Sprintf("HashReader: %s:", test.name), func(t *testing.T) {
+			digest, err := HashReader(test.htype, strings.NewReader(test.in))
+			if err != nil {
+				t.Fatalf("HashReader() error = %v", err)
+			}
+			if digest.Type != test.htype || digest.Hex() != test.out {
+				t.Errorf("HashReader() = %d %s, want %d %s", digest.Type, digest.Hex(), test.htype, test.out)
+			}
+		})
+	}
+	for _, test := range hashBase64TestCases {
+		digest, _ := HashReader(test.htype, strings.NewReader(test.in))
+		if digest.Base64() != test.out {
+			t.Errorf("Base64() = %s, want %s", digest.Base64(), test.out)
+		}
+	}
+	if _, err := HashReader(0, strings.NewReader("")); err != ErrInvalidHashType {
+		t.Errorf("HashReader() error = %v, want %v", err, ErrInvalidHashType)
+	}
+}
+
+// errReader fails after returning some data
+type errReader struct{}
+
+func (errReader) Read(p []byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func TestHashReaderError(t *testing.T) {
+	r := io.MultiReader(strings.NewReader("partial"), errReader{})
+	if _, err := HashReader(SHA256, r); err != io.ErrUnexpectedEOF {
+		t.Errorf("HashReader() error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestHashFile(t *testing.T) {
+	// larger than the copy buffer so the file is read in several parts
+	data := bytes.Repeat([]byte("0123456789abcdef"), 64*1024)
+	path := filepath.Join(t.TempDir(), "upload.bin")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	digest, err := HashFile(SHA512, path)
+	if err != nil {
+		t.Fatalf("HashFile() error = %v", err)
+	}
+	want, _ := Hash(SHA512, data)
+	if !bytes.Equal(digest.Sum, want) || digest.Type != SHA512 {
+		t.Errorf("HashFile() = %x, want %x", digest.Sum, want)
+	}
+	if _, err := HashFile(SHA512, filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Errorf("HashFile() error = %v, want not exist", err)
+	}
+}
+
+func TestHasher(t *testing.T) {
+	hasher, err := NewHasher(SHA256)
+	if err != nil {
+		t.Fatalf("NewHasher() error = %v", err)
+	}
+	hasher.Write([]byte("te"))
+	hasher.Write([]byte("st"))
+	want := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	if digest := hasher.Sum(); digest.Hex() != want {
+		t.Errorf("Sum() = %s, want %s", digest.Hex(), want)
+	}
+	hasher.Reset()
+	hasher.Write([]byte("test"))
+	if digest := hasher.Sum(); digest.Hex() != want {
+		t.Errorf("Sum() after Reset() = %s, want %s", digest.Hex(), want)
+	}
+	if _, err := NewHasher(0); err != ErrInvalidHashType {
+		t.Errorf("NewHasher() error = %v, want %v", err, ErrInvalidHashType)
+	}
+}
@@ -0,0 +1,177 @@
+package hash
+
+// self-describing digest formats:
+// OCI content digests "sha256:<hex>" (https://github.com/opencontainers/image-spec/blob/main/descriptor.md#digests),
+// Subresource Integrity "sha384-<base64>" (https://www.w3.org/TR/SRI/#integrity-metadata)
+// and multihash varint(code) || varint(length) || digest (https://multiformats.io/multihash/)
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+)
+
+// errors
+var (
+	// ErrInvalidDigest indicates a malformed digest string or multihash
+	ErrInvalidDigest = errors.New("invalid digest")
+
+	// ErrUnsupportedDigestFormat indicates a hash algorithm which the digest format can not express
+	ErrUnsupportedDigestFormat = errors.New("hash algorithm not supported by the digest format")
+
+	// ErrDigestMismatch indicates content which does not match the digest
+	ErrDigestMismatch = errors.New("digest mismatch")
+)
+
+// multihash codes of https://github.com/multiformats/multicodec/blob/master/table.csv
+var multihashCodes = map[HashType]uint64{
+	SHA1:       0x11,
+	SHA256:     0x12,
+	SHA512:     0x13,
+	SHA3_512:   0x14,
+	SHA3_384:   0x15,
+	SHA3_256:   0x16,
+	SHA3_224:   0x17,
+	SHAKE128:   0x18,
+	SHAKE256:   0x19,
+	SHA384:     0x20,
+	SHA224:     0x1013,
+	SHA512_224: 0x1014,
+	SHA512_256: 0x1015,
+}
+
+// algorithms allowed in integrity metadata
+var sriTypes = map[HashType]bool{
+	SHA256: true,
+	SHA384: true,
+	SHA512: true,
+}
+
+// String returns the digest as "<algorithm>:<hex>", like sha256:9f86d0...
+// The OCI algorithm grammar has no "/", so SHA-512/256 is written as sha512_256.
+func (d Digest) String() string {
+	return strings.ReplaceAll(d.Type.String(), "/", "_") + ":" + d.Hex()
+}
+
+// SRI returns the digest as Subresource Integrity metadata like sha384-<base64>,
+// only SHA256, SHA384 and SHA512 are allowed
+func (d Digest) SRI() (string, error) {
+	if !sriTypes[d.Type] {
+		return "", ErrUnsupportedDigestFormat
+	}
+	return d.Type.String() + "-" + d.Base64(), nil
+}
+
+// Multihash returns the digest in the binary multihash format
+func (d Digest) Multihash() ([]byte, error) {
+	code, ok := multihashCodes[d.Type]
+	if !ok {
+		return nil, ErrUnsupportedDigestFormat
+	}
+	dst := binary.AppendUvarint(nil, code)
+	dst = binary.AppendUvarint(dst, uint64(len(d.Sum)))
+	return append(dst, d.Sum...), nil
+}
+
+// Equal reports whether both digests have the same algorithm and value,
+// the values are compared in constant time
+func (d Digest) Equal(other Digest) bool {
+	return d.Type == other.Type && subtle.ConstantTimeCompare(d.Sum, other.Sum) == 1
+}
+
+// Verify checks the digest of content in constant time
+func (d Digest) Verify(content []byte) error {
+	return d.VerifyReader(bytes.NewReader(content))
+}
+
+// VerifyReader checks the digest of everything read from r until EOF
+func (d Digest) VerifyReader(r io.Reader) error {
+	actual, err := HashReader(d.Type, r)
+	if err != nil {
+		return err
+	}
+	if !d.Equal(actual) {
+		return ErrDigestMismatch
+	}
+	return nil
+}
+
+// ParseDigest parses "<algorithm>:<hex>" or Subresource Integrity "<algorithm>-<base64>",
+// SRI options after "?" are ignored
+func ParseDigest(s string) (Digest, error) {
+	if name, encoded, ok := strings.Cut(s, ":"); ok {
+		htype, err := ParseHashType(name)
+		if err != nil {
+			return Digest{}, err
+		}
+		sum, err := hex.DecodeString(encoded)
+		if err != nil {
+			return Digest{}, ErrInvalidDigest
+		}
+		return newDigest(htype, sum)
+	}
+
+	name, encoded, ok := strings.Cut(s, "-")
+	if !ok {
+		return Digest{}, ErrInvalidDigest
+	}
+	htype, err := ParseHashType(name)
+	if err != nil {
+		return Digest{}, err
+	}
+	if !sriTypes[htype] {
+		return Digest{}, ErrUnsupportedDigestFormat
+	}
+	encoded, _, _ = strings.Cut(encoded, "?")
+	sum, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return Digest{}, ErrInvalidDigest
+	}
+	return newDigest(htype, sum)
+}
+
+// ParseMultihash parses a binary multihash
+func ParseMultihash(src []byte) (Digest, error) {
+	code, n := binary.Uvarint(src)
+	if n <= 0 {
+		return Digest{}, ErrInvalidDigest
+	}
+	src = src[n:]
+	size, n := binary.Uvarint(src)
+	if n <= 0 || uint64(len(src)-n) != size {
+		return Digest{}, ErrInvalidDigest
+	}
+	for htype, c := range multihashCodes {
+		if c == code {
+			return newDigest(htype, src[n:])
+		}
+	}
+	return Digest{}, ErrUnsupportedDigestFormat
+}
+
+// VerifyDigest checks content against a digest string of ParseDigest,
+// the hash algorithm is taken from the digest string
+func VerifyDigest(digest string, content []byte) error {
+	d, err := ParseDigest(digest)
+	if err != nil {
+		return err
+	}
+	return d.Verify(content)
+}
+
+// newDigest checks that sum has the size of the hash algorithm
+func newDigest(htype HashType, sum []byte) (Digest, error) {
+	h, err := New(htype)
+	if err != nil {
+		return Digest{}, err
+	}
+	if len(sum) != h.Size() {
+		return Digest{}, ErrInvalidDigest
+	}
+	return Digest{Type: htype, Sum: sum}, nil
+}
@@ -0,0 +1,168 @@
+package hash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var helloWorld = []byte("hello world")
+
+func TestParseDigest(t *testing.T) {
+	tests := []struct {
+		in    string
+		htype HashType
+		hex   string
+	}{
+		{
+			in:    "sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+			htype: SHA256,
+			hex:   "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		},
+		{
+			in:    "sha3-256:644bcc7e564373040999aac89e7622f3ca71fba1d972fd94a31c3bfbf24e3938",
+			htype: SHA3_256,
+			hex:   "644bcc7e564373040999aac89e7622f3ca71fba1d972fd94a31c3bfbf24e3938",
+		},
+		{
+			in:    "sha512-MJ7MSJwS1utMxA9QyQLytNDtd+5RGnx6m808qG1M2G+YndNbxf9JlnDaNCVbRbDP2DDoH2Bdz33FVC6TrpzXbw==",
+			htype: SHA512,
+			hex:   "309ecc489c12d6eb4cc40f50c902f2b4d0ed77ee511a7c7a9bcd3ca86d4cd86f989dd35bc5ff499670da34255b45b0cfd830e81f605dcf7dc5542e93ae9cd76f",
+		},
+		{
+			in:    "sha256-uU0nuZNNPgilLlLX2n2r+sSE7+N6U4DukIj3rOLvzek=?ct=text/plain",
+			htype: SHA256,
+			hex:   "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+		},
+	}
+	for _, tt := range tests {
+		d, err := ParseDigest(tt.in)
+		if err != nil {
+			t.Errorf("ParseDigest(%q) error = %v", tt.in, err)
+			continue
+		}
+		if d.Type != tt.htype || d.Hex() != tt.hex {
+			t.Errorf("ParseDigest(%q) = %v, want %v:%s", tt.in, d, tt.htype, tt.hex)
+		}
+		if err := VerifyDigest(tt.in, helloWorld); err != nil {
+			t.Errorf("VerifyDigest(%q) error = %v", tt.in, err)
+		}
+		if err := VerifyDigest(tt.in, []byte("hello world!")); err != ErrDigestMismatch {
+			t.Errorf("VerifyDigest(%q) of other content error = %v, want %v", tt.in, err, ErrDigestMismatch)
+		}
+	}
+}
+
+func TestParseDigestErrors(t *testing.T) {
+	tests := []struct {
+		in  string
+		err error
+	}{
+		{"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", ErrInvalidDigest},
+		{"sha256:b94d27b9934d3e08", ErrInvalidDigest},
+		{"sha256:not hex", ErrInvalidDigest},
+		{"md5:5eb63bbbe01eeed093cb22bb8f5acdc3", ErrInvalidHashType},
+		{"sha1-Kq5sNclPz7QV2+lfQIuc6R7oRu0=", ErrUnsupportedDigestFormat},
+		{"sha256-!!!", ErrInvalidDigest},
+	}
+	for _, tt := range tests {
+		if _, err := ParseDigest(tt.in); err != tt.err {
+			t.Errorf("ParseDigest(%q) error = %v, want %v", tt.in, err, tt.err)
+		}
+	}
+}
+
+func TestDigestFormats(t *testing.T) {
+	d, _ := HashReader(SHA384, bytes.NewReader(helloWorld))
+	if s := d.String(); !strings.HasPrefix(s, "sha384:fdbd8e75") {
+		t.Errorf("String() = %s", s)
+	}
+	sri, err := d.SRI()
+	if err != nil {
+		t.Fatalf("SRI() error = %v", err)
+	}
+	if !strings.HasPrefix(sri, "sha384-/b2OdaZ") {
+		t.Errorf("SRI() = %s", sri)
+	}
+	for _, s := range []string{d.String(), sri} {
+		parsed, err := ParseDigest(s)
+		if err != nil || !parsed.Equal(d) {
+			t.Errorf("ParseDigest(%q) = %v, %v, want %v", s, parsed, err, d)
+		}
+	}
+	if _, err := (Digest{Type: SHA3_256, Sum: d.Sum}).SRI(); err != ErrUnsupportedDigestFormat {
+		t.Errorf("SRI() of SHA3-256 error = %v, want %v", err, ErrUnsupportedDigestFormat)
+	}
+
+	// https://github.com/opencontainers/image-spec/blob/main/descriptor.md#digests
+	algorithm := regexp.MustCompile(`^[a-z0-9]+(?:[+._-][a-z0-9]+)*:`)
+	for _, htype := range []HashType{SHA512_224, SHA512_256, SHA3_256} {
+		d, _ := HashReader(htype, bytes.NewReader(helloWorld))
+		s := d.String()
+		if !algorithm.MatchString(s) {
+			t.Errorf("String() = %s is not an OCI digest", s)
+		}
+		if parsed, err := ParseDigest(s); err != nil || !parsed.Equal(d) {
+			t.Errorf("ParseDigest(%q) = %v, %v, want %v", s, parsed, err, d)
+		}
+	}
+}
+
+func TestMultihash(t *testing.T) {
+	tests := []struct {
+		htype  HashType
+		prefix string
+	}{
+		{SHA1, "1114"},
+		{SHA256, "1220"},
+		{SHA512, "1340"},
+		{SHA3_256, "1620"},
+		{SHAKE256, "1940"},
+		{SHA224, "93201c"},
+		{SHA512_256, "952020"},
+	}
+	for _, tt := range tests {
+		d, _ := HashReader(tt.htype, bytes.NewReader(helloWorld))
+		mh, err := d.Multihash()
+		if err != nil {
+			t.Fatalf("Multihash() error = %v", err)
+		}
+		if got := hex.EncodeToString(mh); got != tt.prefix+d.Hex() {
+			t.Errorf("Multihash() of %v = %s, want prefix %s", tt.htype, got, tt.prefix)
+		}
+		parsed, err := ParseMultihash(mh)
+		if err != nil || !parsed.Equal(d) {
+			t.Errorf("ParseMultihash() = %v, %v, want %v", parsed, err, d)
+		}
+		if _, err := ParseMultihash(mh[:len(mh)-1]); err != ErrInvalidDigest {
+			t.Errorf("ParseMultihash() of a truncated multihash error = %v, want %v", err, ErrInvalidDigest)
+		}
+	}
+	// identity hash
+	if _, err := ParseMultihash([]byte{0x00, 0x01, 0x61}); err != ErrUnsupportedDigestFormat {
+		t.Errorf("ParseMultihash() error = %v, want %v", err, ErrUnsupportedDigestFormat)
+	}
+	if _, err := ParseMultihash(nil); err != ErrInvalidDigest {
+		t.Errorf("ParseMultihash() error = %v, want %v", err, ErrInvalidDigest)
+	}
+}
+
+func TestDigestEqual(t *testing.T) {
+	a, _ := HashReader(SHA256, bytes.NewReader(helloWorld))
+	b, _ := HashReader(SHA256, bytes.NewReader(helloWorld))
+	c, _ := HashReader(SHA256, bytes.NewReader([]byte("hello")))
+	if !a.Equal(b) {
+		t.Errorf("Equal() of the same digest = false")
+	}
+	if a.Equal(c) {
+		t.Errorf("Equal() of different digests = true")
+	}
+	if a.Equal(Digest{Type: SHA3_256, Sum: a.Sum}) {
+		t.Errorf("Equal() of different algorithms = true")
+	}
+	if err := a.VerifyReader(bytes.NewReader([]byte("hello"))); err != ErrDigestMismatch {
+		t.Errorf("VerifyReader() error = %v, want %v", err, ErrDigestMismatch)
+	}
+}
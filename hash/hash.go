@@ -1,11 +1,10 @@
 // hash package implements hash functions.
-// It currently supports SHA1, SHA256.
+// It currently supports SHA1, the SHA-2 family, SHA-3 and the SHAKE extendable-output functions,
+// other algorithms can be added with Register.
 package hash
 
 import (
 	"crypto"
-	"crypto/sha1"
-	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
 	"errors"
@@ -16,8 +15,19 @@ type HashType uint
 
 // types of supported hashing algorithms
 const (
-	SHA1   HashType = 1 + iota // http://en.wikipedia.org/wiki/SHA-1
-	SHA256                     // https://en.wikipedia.org/wiki/SHA-2
+	SHA1       HashType = 1 + iota // http://en.wikipedia.org/wiki/SHA-1
+	SHA256                         // https://en.wikipedia.org/wiki/SHA-2
+	SHA224                         // https://en.wikipedia.org/wiki/SHA-2
+	SHA384                         // https://en.wikipedia.org/wiki/SHA-2
+	SHA512                         // https://en.wikipedia.org/wiki/SHA-2
+	SHA512_224                     // https://en.wikipedia.org/wiki/SHA-2
+	SHA512_256                     // https://en.wikipedia.org/wiki/SHA-2
+	SHA3_224                       // https://en.wikipedia.org/wiki/SHA-3
+	SHA3_256                       // https://en.wikipedia.org/wiki/SHA-3
+	SHA3_384                       // https://en.wikipedia.org/wiki/SHA-3
+	SHA3_512                       // https://en.wikipedia.org/wiki/SHA-3
+	SHAKE128                       // extendable output, 32 bytes from Hash and New
+	SHAKE256                       // extendable output, 64 bytes from Hash and New
 )
 
 // errors
@@ -25,28 +35,30 @@ var (
 	ErrInvalidHashType = errors.New("invalid hash type")
 )
 
-// get standard crypto hash value from hash type like crypto.SHA1 from SHA1
+// get standard crypto hash value from hash type like crypto.SHA1 from SHA1,
+// SHAKE128, SHAKE256 and registered algorithms have no standard crypto hash value
 func GetStdCryptoHash(htype HashType) (crypto.Hash, error) {
-	switch htype {
-	case SHA1:
-		return crypto.SHA1, nil
-	case SHA256:
-		return crypto.SHA256, nil
-	default:
+	alg, ok := lookup(htype)
+	if !ok || alg.std == 0 {
 		return 0, ErrInvalidHashType
 	}
+	return alg.std, nil
+}
+
+// New returns a new hash.Hash computing the given hash algorithm
+func New(htype HashType) (hash.Hash, error) {
+	newFunc, err := NewFunc(htype)
+	if err != nil {
+		return nil, err
+	}
+	return newFunc(), nil
 }
 
 // Hash hashes data using a given hash algorithm
 func Hash(htype HashType, src []byte) ([]byte, error) {
-	var h hash.Hash
-	switch htype {
-	case SHA1:
-		h = sha1.New()
-	case SHA256:
-		h = sha256.New()
-	default:
-		return nil, ErrInvalidHashType
+	h, err := New(htype)
+	if err != nil {
+		return nil, err
 	}
 	h.Write(src)
 	return h.Sum(nil), nil
@@ -36,6 +36,74 @@ var hashTestCases = []struct {
 		out:   "da39a3ee5e6b4b0d3255bfef95601890afd80709",
 		htype: SHA1,
 	},
+	// FIPS 180-4 examples
+	{
+		name:  "SHA224#1",
+		in:    "abc",
+		out:   "23097d223405d8228642a477bda255b32aadbce4bda0b3f7e36c9da7",
+		htype: SHA224,
+	},
+	{
+		name:  "SHA384#1",
+		in:    "abc",
+		out:   "cb00753f45a35e8bb5a03d699ac65007272c32ab0eded1631a8b605a43ff5bed8086072ba1e7cc2358baeca134c825a7",
+		htype: SHA384,
+	},
+	{
+		name:  "SHA512#1",
+		in:    "abc",
+		out:   "ddaf35a193617abacc417349ae20413112e6fa4e89a97ea20a9eeee64b55d39a2192992a274fc1a836ba3c23a3feebbd454d4423643ce80e2a9ac94fa54ca49f",
+		htype: SHA512,
+	},
+	{
+		name:  "SHA512/224#1",
+		in:    "abc",
+		out:   "4634270f707b6a54daae7530460842e20e37ed265ceee9a43e8924aa",
+		htype: SHA512_224,
+	},
+	{
+		name:  "SHA512/256#1",
+		in:    "abc",
+		out:   "53048e2681941ef99b2e29b76b4c7dabe4c2d0c634fc6d46e0e2f13107e7af23",
+		htype: SHA512_256,
+	},
+	// FIPS 202 examples
+	{
+		name:  "SHA3-224#1",
+		in:    "abc",
+		out:   "e642824c3f8cf24ad09234ee7d3c766fc9a3a5168d0c94ad73b46fdf",
+		htype: SHA3_224,
+	},
+	{
+		name:  "SHA3-256#1",
+		in:    "abc",
+		out:   "3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532",
+		htype: SHA3_256,
+	},
+	{
+		name:  "SHA3-384#1",
+		in:    "abc",
+		out:   "ec01498288516fc926459f58e2c6ad8df9b473cb0fc08c2596da7cf0e49be4b298d88cea927ac7f539f1edf228376d25",
+		htype: SHA3_384,
+	},
+	{
+		name:  "SHA3-512#1",
+		in:    "abc",
+		out:   "b751850b1a57168a5693cd924b6b096e08f621827444f70d884f5d0240d2712e10e116e9192af3c91a7ec57647e3934057340b4cf408d5a56592f8274eec53f0",
+		htype: SHA3_512,
+	},
+	{
+		name:  "SHAKE128#1",
+		in:    "abc",
+		out:   "5881092dd818bf5cf8a3ddb793fbcba74097d5c526a6d35f97b83351940f2cc8",
+		htype: SHAKE128,
+	},
+	{
+		name:  "SHAKE256#1",
+		in:    "abc",
+		out:   "483366601360a8771c6863080cc4114d8db44530f8f1e1ee4f94ea37e78b5739d5a15bef186a5386c75744c0527e1faa9f8726e462a12a4feb06bd8801e751e4",
+		htype: SHAKE256,
+	},
 }
 
 func TestHash(t *testing.T) {
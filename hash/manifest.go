@@ -0,0 +1,273 @@
+package hash
+
+// checksum manifests in the format of sha256sum and the other GNU coreutils checksum tools
+// https://www.gnu.org/software/coreutils/manual/html_node/md5sum-invocation.html
+//
+//	<hex digest>  <path>
+//
+// and the Go module directory hash "h1:" of golang.org/x/mod/sumdb/dirhash
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// errors
+var (
+	// ErrInvalidManifest indicates a malformed checksum manifest
+	ErrInvalidManifest = errors.New("invalid checksum manifest")
+)
+
+// ManifestEntry is the digest of one file, Path is slash separated and relative to the directory
+type ManifestEntry struct {
+	Path string
+	Sum  []byte
+}
+
+// Manifest lists the digests of the files of a directory tree, sorted by path
+type Manifest struct {
+	Type    HashType
+	Entries []ManifestEntry
+}
+
+// ManifestReport lists the differences between a manifest and a directory
+type ManifestReport struct {
+	Missing    []string // in the manifest but not in the directory
+	Extra      []string // in the directory but not in the manifest
+	Mismatched []string // with another digest than in the manifest
+}
+
+// OK reports whether the directory matches the manifest
+func (r *ManifestReport) OK() bool {
+	return len(r.Missing) == 0 && len(r.Extra) == 0 && len(r.Mismatched) == 0
+}
+
+// GenerateManifest hashes all regular files under dir in parallel, other files like
+// symbolic links are skipped
+func GenerateManifest(htype HashType, dir string) (*Manifest, error) {
+	if _, err := New(htype); err != nil {
+		return nil, err
+	}
+	paths, err := listFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	sums, err := hashFiles(htype, dir, paths)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{Type: htype, Entries: make([]ManifestEntry, len(paths))}
+	for i, path := range paths {
+		m.Entries[i] = ManifestEntry{Path: path, Sum: sums[i]}
+	}
+	return m, nil
+}
+
+// Verify hashes the files of dir in parallel and compares them with the manifest
+func (m *Manifest) Verify(dir string) (*ManifestReport, error) {
+	paths, err := listFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		present[path] = true
+	}
+
+	report := &ManifestReport{}
+	listed := make(map[string]bool, len(m.Entries))
+	var entries []ManifestEntry
+	for _, entry := range m.Entries {
+		listed[entry.Path] = true
+		if present[entry.Path] {
+			entries = append(entries, entry)
+		} else {
+			report.Missing = append(report.Missing, entry.Path)
+		}
+	}
+	for _, path := range paths {
+		if !listed[path] {
+			report.Extra = append(report.Extra, path)
+		}
+	}
+
+	toHash := make([]string, len(entries))
+	for i, entry := range entries {
+		toHash[i] = entry.Path
+	}
+	sums, err := hashFiles(m.Type, dir, toHash)
+	if err != nil {
+		return nil, err
+	}
+	for i, entry := range entries {
+		if !(Digest{Type: m.Type, Sum: entry.Sum}).Equal(Digest{Type: m.Type, Sum: sums[i]}) {
+			report.Mismatched = append(report.Mismatched, entry.Path)
+		}
+	}
+	return report, nil
+}
+
+// WriteTo writes the manifest in the sha256sum format, names with a newline, a carriage return
+// or a backslash are escaped like sha256sum does
+func (m *Manifest) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+	for _, entry := range m.Entries {
+		path := entry.Path
+		if strings.ContainsAny(path, "\\\n\r") {
+			b.WriteByte('\\')
+			path = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r").Replace(path)
+		}
+		b.WriteString(hex.EncodeToString(entry.Sum) + "  " + path + "\n")
+	}
+	return b.WriteTo(w)
+}
+
+// ParseManifest reads a manifest in the sha256sum format, in text or binary (*) mode
+func ParseManifest(htype HashType, r io.Reader) (*Manifest, error) {
+	h, err := New(htype)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{Type: htype}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		// a carriage return in a name is escaped, so a trailing one is a CRLF line ending
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		escaped := strings.HasPrefix(line, "\\")
+		if escaped {
+			line = line[1:]
+		}
+		encoded, path, ok := strings.Cut(line, " ")
+		if !ok || len(path) < 2 || (path[0] != ' ' && path[0] != '*') {
+			return nil, fmt.Errorf("%w: line %d", ErrInvalidManifest, n)
+		}
+		path = path[1:]
+		if escaped {
+			if path, ok = unescapeManifestPath(path); !ok {
+				return nil, fmt.Errorf("%w: line %d", ErrInvalidManifest, n)
+			}
+		}
+		sum, err := hex.DecodeString(encoded)
+		if err != nil || len(sum) != h.Size() {
+			return nil, fmt.Errorf("%w: line %d", ErrInvalidManifest, n)
+		}
+		m.Entries = append(m.Entries, ManifestEntry{Path: strings.TrimPrefix(path, "./"), Sum: sum})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(m.Entries, func(a, b ManifestEntry) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return m, nil
+}
+
+func unescapeManifestPath(path string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] != '\\' {
+			b.WriteByte(path[i])
+			continue
+		}
+		i++
+		if i == len(path) {
+			return "", false
+		}
+		switch path[i] {
+		case '\\':
+			b.WriteByte('\\')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// HashDir returns the Go module directory hash "h1:<base64>" of the files under dir,
+// named prefix/path like module@version/go.mod, the same as dirhash.HashDir with dirhash.Hash1.
+// It is the SHA-256 of the SHA-256 manifest of the files.
+func HashDir(dir, prefix string) (string, error) {
+	m, err := GenerateManifest(SHA256, dir)
+	if err != nil {
+		return "", err
+	}
+	// names are written as they are, without the escaping of WriteTo
+	h := sha256.New()
+	for _, entry := range m.Entries {
+		if strings.Contains(entry.Path, "\n") {
+			return "", fmt.Errorf("%w: file name with a newline", ErrInvalidManifest)
+		}
+		fmt.Fprintf(h, "%x  %s/%s\n", entry.Sum, prefix, entry.Path)
+	}
+	return "h1:" + base64.StdEncoding.EncodeToString(h.Sum(nil)), nil
+}
+
+// listFiles returns the slash separated paths of the regular files under dir, sorted
+func listFiles(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(paths)
+	return paths, nil
+}
+
+// hashFiles hashes the files with one goroutine per CPU
+func hashFiles(htype HashType, dir string, paths []string) ([][]byte, error) {
+	sums := make([][]byte, len(paths))
+	errs := make([]error, len(paths))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(runtime.GOMAXPROCS(0), len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				d, err := HashFile(htype, filepath.Join(dir, filepath.FromSlash(paths[i])))
+				sums[i], errs[i] = d.Sum, err
+			}
+		}()
+	}
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
@@ -0,0 +1,184 @@
+package hash
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// output of sha256sum a.txt sub/b.txt 'sub/back\slash'
+const testManifest = `9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  a.txt
+a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447  sub/b.txt
+\e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  sub/back\\slash
+`
+
+func writeTestTree(t *testing.T) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.txt":           "test",
+		"sub/b.txt":       "hello world\n",
+		"sub/back\\slash": "",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// symbolic links are not part of the manifest
+	if err := os.Symlink("a.txt", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestGenerateManifest(t *testing.T) {
+	dir := writeTestTree(t)
+	m, err := GenerateManifest(SHA256, dir)
+	if err != nil {
+		t.Fatalf("GenerateManifest() error = %v", err)
+	}
+	var b bytes.Buffer
+	if _, err := m.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if b.String() != testManifest {
+		t.Errorf("WriteTo() = %q, want %q", b.String(), testManifest)
+	}
+
+	parsed, err := ParseManifest(SHA256, strings.NewReader(testManifest))
+	if err != nil {
+		t.Fatalf("ParseManifest() error = %v", err)
+	}
+	if len(parsed.Entries) != 3 || parsed.Entries[2].Path != "sub/back\\slash" {
+		t.Errorf("ParseManifest() = %+v", parsed.Entries)
+	}
+	report, err := parsed.Verify(dir)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if !report.OK() {
+		t.Errorf("Verify() = %+v, want no differences", report)
+	}
+
+	if _, err := GenerateManifest(0, dir); err != ErrInvalidHashType {
+		t.Errorf("GenerateManifest() error = %v, want %v", err, ErrInvalidHashType)
+	}
+	if _, err := GenerateManifest(SHA256, filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("GenerateManifest() of a missing directory error = %v", err)
+	}
+}
+
+func TestManifestVerifyReport(t *testing.T) {
+	dir := writeTestTree(t)
+	m, err := GenerateManifest(SHA3_256, dir)
+	if err != nil {
+		t.Fatalf("GenerateManifest() error = %v", err)
+	}
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("changed"), 0o644)
+	os.Remove(filepath.Join(dir, "sub", "b.txt"))
+	os.WriteFile(filepath.Join(dir, "sub", "new.txt"), []byte("new"), 0o644)
+
+	report, err := m.Verify(dir)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if report.OK() {
+		t.Errorf("Verify() OK after changes")
+	}
+	if !slices.Equal(report.Mismatched, []string{"a.txt"}) {
+		t.Errorf("Mismatched = %v", report.Mismatched)
+	}
+	if !slices.Equal(report.Missing, []string{"sub/b.txt"}) {
+		t.Errorf("Missing = %v", report.Missing)
+	}
+	if !slices.Equal(report.Extra, []string{"sub/new.txt"}) {
+		t.Errorf("Extra = %v", report.Extra)
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	// binary mode, ./ prefixes, CRLF and an escaped newline
+	in := "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447 *./sub/b.txt\r\n" +
+		"\n" +
+		"\\9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  new\\nline\n"
+	m, err := ParseManifest(SHA256, strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ParseManifest() error = %v", err)
+	}
+	if len(m.Entries) != 2 || m.Entries[0].Path != "new\nline" || m.Entries[1].Path != "sub/b.txt" {
+		t.Errorf("ParseManifest() = %+v", m.Entries)
+	}
+	var b bytes.Buffer
+	m.WriteTo(&b)
+	if !strings.HasPrefix(b.String(), "\\9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  new\\nline\n") {
+		t.Errorf("WriteTo() = %q", b.String())
+	}
+
+	// a carriage return at the end of a name survives a round trip
+	m = &Manifest{Type: SHA256, Entries: []ManifestEntry{{Path: "back\\slash\r", Sum: m.Entries[0].Sum}}}
+	b.Reset()
+	m.WriteTo(&b)
+	if want := "\\9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  back\\\\slash\\r\n"; b.String() != want {
+		t.Errorf("WriteTo() = %q, want %q", b.String(), want)
+	}
+	parsed, err := ParseManifest(SHA256, &b)
+	if err != nil || len(parsed.Entries) != 1 || parsed.Entries[0].Path != "back\\slash\r" {
+		t.Errorf("ParseManifest() = %+v, %v", parsed, err)
+	}
+
+	for _, line := range []string{
+		"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08",
+		"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08 a.txt",
+		"9f86d081  a.txt",
+		"not hex  a.txt",
+		"\\9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08  a\\t",
+	} {
+		if _, err := ParseManifest(SHA256, strings.NewReader(line)); !errors.Is(err, ErrInvalidManifest) {
+			t.Errorf("ParseManifest(%q) error = %v, want %v", line, err, ErrInvalidManifest)
+		}
+	}
+}
+
+func TestHashDir(t *testing.T) {
+	dir := writeTestTree(t)
+	// SHA-256 of the manifest lines "<hex>  example.com/m@v1.0.0/<path>\n", computed with Python
+	want := "h1:ub0ERubZzDc+2PbVFaT12cKM1X7Ay8W3l/b2uMJGK5g="
+	h1, err := HashDir(dir, "example.com/m@v1.0.0")
+	if err != nil {
+		t.Fatalf("HashDir() error = %v", err)
+	}
+	if h1 != want {
+		t.Errorf("HashDir() = %s, want %s", h1, want)
+	}
+
+	os.WriteFile(filepath.Join(dir, "new\nline"), nil, 0o644)
+	if _, err := HashDir(dir, "example.com/m@v1.0.0"); !errors.Is(err, ErrInvalidManifest) {
+		t.Errorf("HashDir() with a newline in a name error = %v, want %v", err, ErrInvalidManifest)
+	}
+}
+
+func TestGenerateManifestParallel(t *testing.T) {
+	dir := t.TempDir()
+	for i := range 100 {
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("file%03d", i)), []byte(strings.Repeat("x", i)), 0o644)
+	}
+	m, err := GenerateManifest(SHA512, dir)
+	if err != nil {
+		t.Fatalf("GenerateManifest() error = %v", err)
+	}
+	for _, entry := range m.Entries {
+		d, _ := HashFile(SHA512, filepath.Join(dir, entry.Path))
+		if !bytes.Equal(d.Sum, entry.Sum) {
+			t.Errorf("GenerateManifest() digest of %s = %x, want %x", entry.Path, entry.Sum, d.Sum)
+		}
+	}
+}
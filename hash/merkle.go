@@ -0,0 +1,285 @@
+package hash
+
+// Merkle tree hashing with the domain separation of RFC 6962 (Certificate Transparency)
+// https://datatracker.ietf.org/doc/html/rfc6962#section-2.1
+// Leaves are hashed as H(0x00 || leaf) and inner nodes as H(0x01 || left || right).
+// Proofs are generated as in RFC 6962 and verified with the algorithms of RFC 9162
+// https://datatracker.ietf.org/doc/html/rfc9162#section-2.1
+
+import (
+	"bytes"
+	"errors"
+	"hash"
+	"math/bits"
+	"runtime"
+	"sync"
+)
+
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+
+	// levels with fewer nodes are hashed by a single goroutine
+	merkleParallelThreshold = 1024
+)
+
+// errors
+var (
+	// ErrIndexOutOfRange indicates a leaf index or tree size larger than the tree
+	ErrIndexOutOfRange = errors.New("merkle tree index out of range")
+
+	// ErrInvalidProof indicates an inclusion or consistency proof which does not verify
+	ErrInvalidProof = errors.New("invalid merkle proof")
+)
+
+// MerkleTree is a Merkle tree over a list of leaves
+type MerkleTree struct {
+	htype HashType
+	// levels[0] are the leaf hashes and the last level is the root. A node without
+	// a sibling is carried to the next level unchanged, which gives the RFC 6962 tree.
+	levels [][][]byte
+}
+
+// NewMerkleTree hashes the leaves in parallel and builds the tree over them
+func NewMerkleTree(htype HashType, leaves [][]byte) (*MerkleTree, error) {
+	if _, err := New(htype); err != nil {
+		return nil, err
+	}
+	t := &MerkleTree{htype: htype}
+	if len(leaves) == 0 {
+		return t, nil
+	}
+
+	level := make([][]byte, len(leaves))
+	parallelFor(len(leaves), func(start, end int) {
+		h, _ := New(htype)
+		for i := start; i < end; i++ {
+			level[i] = merkleHash(h, merkleLeafPrefix, leaves[i])
+		}
+	})
+	t.levels = append(t.levels, level)
+
+	for len(level) > 1 {
+		next := make([][]byte, (len(level)+1)/2)
+		parallelFor(len(level)/2, func(start, end int) {
+			h, _ := New(htype)
+			for i := start; i < end; i++ {
+				next[i] = merkleHash(h, merkleNodePrefix, level[2*i], level[2*i+1])
+			}
+		})
+		if len(level)%2 == 1 {
+			next[len(next)-1] = level[len(level)-1]
+		}
+		t.levels = append(t.levels, next)
+		level = next
+	}
+	return t, nil
+}
+
+// parallelFor calls fn on ranges which cover [0, n), in parallel for large n
+func parallelFor(n int, fn func(start, end int)) {
+	workers := runtime.GOMAXPROCS(0)
+	if n < merkleParallelThreshold || workers == 1 {
+		fn(0, n)
+		return
+	}
+	size := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			fn(start, end)
+		}(start, end)
+	}
+	wg.Wait()
+}
+
+func merkleHash(h hash.Hash, prefix byte, parts ...[]byte) []byte {
+	h.Reset()
+	h.Write([]byte{prefix})
+	for _, part := range parts {
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
+
+// MerkleLeafHash returns the hash of a leaf, H(0x00 || leaf)
+func MerkleLeafHash(htype HashType, leaf []byte) ([]byte, error) {
+	h, err := New(htype)
+	if err != nil {
+		return nil, err
+	}
+	return merkleHash(h, merkleLeafPrefix, leaf), nil
+}
+
+func merkleNodeHash(htype HashType, left, right []byte) []byte {
+	h, _ := New(htype)
+	return merkleHash(h, merkleNodePrefix, left, right)
+}
+
+// Size returns the number of leaves
+func (t *MerkleTree) Size() int {
+	if len(t.levels) == 0 {
+		return 0
+	}
+	return len(t.levels[0])
+}
+
+// Root returns the root hash, the root of an empty tree is the hash of the empty string
+func (t *MerkleTree) Root() []byte {
+	if len(t.levels) == 0 {
+		h, _ := New(t.htype)
+		return h.Sum(nil)
+	}
+	return t.levels[len(t.levels)-1][0]
+}
+
+// subtreeHash returns the hash of the leaves [start, start+size), which must be a node
+// of the tree: size is a power of two or the range ends at the last leaf
+func (t *MerkleTree) subtreeHash(start, size int) []byte {
+	level := bits.Len(uint(size - 1))
+	return t.levels[level][start>>level]
+}
+
+// splitSize returns the largest power of two smaller than n
+func splitSize(n int) int {
+	return 1 << (bits.Len(uint(n-1)) - 1)
+}
+
+// InclusionProof returns the audit path of the leaf at index (RFC 6962 section 2.1.1)
+func (t *MerkleTree) InclusionProof(index int) ([][]byte, error) {
+	if index < 0 || index >= t.Size() {
+		return nil, ErrIndexOutOfRange
+	}
+	return t.inclusionPath(index, 0, t.Size()), nil
+}
+
+func (t *MerkleTree) inclusionPath(index, start, size int) [][]byte {
+	if size == 1 {
+		return nil
+	}
+	k := splitSize(size)
+	if index < k {
+		return append(t.inclusionPath(index, start, k), t.subtreeHash(start+k, size-k))
+	}
+	return append(t.inclusionPath(index-k, start+k, size-k), t.subtreeHash(start, k))
+}
+
+// ConsistencyProof proves that the first oldSize leaves form a prefix of the tree
+// (RFC 6962 section 2.1.2)
+func (t *MerkleTree) ConsistencyProof(oldSize int) ([][]byte, error) {
+	if oldSize < 0 || oldSize > t.Size() {
+		return nil, ErrIndexOutOfRange
+	}
+	if oldSize == 0 {
+		return nil, nil
+	}
+	return t.consistencyProof(oldSize, 0, t.Size(), true), nil
+}
+
+func (t *MerkleTree) consistencyProof(m, start, size int, complete bool) [][]byte {
+	if m == size {
+		if complete {
+			return nil
+		}
+		return [][]byte{t.subtreeHash(start, size)}
+	}
+	k := splitSize(size)
+	if m <= k {
+		return append(t.consistencyProof(m, start, k, complete), t.subtreeHash(start+k, size-k))
+	}
+	return append(t.consistencyProof(m-k, start+k, size-k, false), t.subtreeHash(start, k))
+}
+
+// VerifyInclusion checks that leaf is at index in the tree of size leaves with the root
+func VerifyInclusion(htype HashType, leaf []byte, index, size int, proof [][]byte, root []byte) error {
+	if index < 0 || index >= size {
+		return ErrIndexOutOfRange
+	}
+	r, err := MerkleLeafHash(htype, leaf)
+	if err != nil {
+		return err
+	}
+	fn, sn := index, size-1
+	for _, p := range proof {
+		if sn == 0 {
+			return ErrInvalidProof
+		}
+		if fn&1 == 1 || fn == sn {
+			r = merkleNodeHash(htype, p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = merkleNodeHash(htype, r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 || !bytes.Equal(r, root) {
+		return ErrInvalidProof
+	}
+	return nil
+}
+
+// VerifyConsistency checks that the tree of oldSize leaves with oldRoot is a prefix of
+// the tree of newSize leaves with newRoot
+func VerifyConsistency(htype HashType, oldSize, newSize int, oldRoot, newRoot []byte, proof [][]byte) error {
+	if oldSize < 0 || oldSize > newSize {
+		return ErrIndexOutOfRange
+	}
+	if _, err := New(htype); err != nil {
+		return err
+	}
+	if oldSize == 0 {
+		if len(proof) != 0 {
+			return ErrInvalidProof
+		}
+		return nil
+	}
+	if oldSize == newSize {
+		if len(proof) != 0 || !bytes.Equal(oldRoot, newRoot) {
+			return ErrInvalidProof
+		}
+		return nil
+	}
+	if len(proof) == 0 {
+		return ErrInvalidProof
+	}
+	if oldSize&(oldSize-1) == 0 {
+		proof = append([][]byte{oldRoot}, proof...)
+	}
+	fn, sn := oldSize-1, newSize-1
+	for fn&1 == 1 {
+		fn >>= 1
+		sn >>= 1
+	}
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return ErrInvalidProof
+		}
+		if fn&1 == 1 || fn == sn {
+			fr = merkleNodeHash(htype, c, fr)
+			sr = merkleNodeHash(htype, c, sr)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			sr = merkleNodeHash(htype, sr, c)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+	if sn != 0 || !bytes.Equal(fr, oldRoot) || !bytes.Equal(sr, newRoot) {
+		return ErrInvalidProof
+	}
+	return nil
+}
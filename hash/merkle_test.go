@@ -0,0 +1,149 @@
+package hash
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+// RFC 6962 reference leaves and roots, as used by the certificate transparency implementations
+var merkleTestLeaves = [][]byte{
+	{},
+	{0x00},
+	{0x10},
+	{0x20, 0x21},
+	{0x30, 0x31},
+	{0x40, 0x41, 0x42, 0x43},
+	{0x50, 0x51, 0x52, 0x53, 0x54, 0x55, 0x56, 0x57},
+	{0x60, 0x61, 0x62, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68, 0x69, 0x6a, 0x6b, 0x6c, 0x6d, 0x6e, 0x6f},
+}
+
+var merkleTestRoots = []string{
+	"6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+	"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+	"aeb6bcfe274b70a14fb067a5e5578264db0fa9b51af5e0ba159158f329e06e77",
+	"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+	"4e3bbb1f7b478dcfe71fb631631519a3bca12c9aefca1612bfce4c13a86264d4",
+	"76e67dadbcdf1e10e1b74ddc608abd2f98dfb16fbce75277b5232a127f2087ef",
+	"ddb89be403809e325750d3d263cd78929c2942b7942a34b77e122c9594a74c8c",
+	"5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+}
+
+func TestMerkleRootRFC6962(t *testing.T) {
+	for i, want := range merkleTestRoots {
+		tree, err := NewMerkleTree(SHA256, merkleTestLeaves[:i+1])
+		if err != nil {
+			t.Fatalf("NewMerkleTree() error = %v", err)
+		}
+		if root := hex.EncodeToString(tree.Root()); root != want {
+			t.Errorf("Root() of %d leaves = %s, want %s", i+1, root, want)
+		}
+	}
+	empty, _ := NewMerkleTree(SHA256, nil)
+	want := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	if root := hex.EncodeToString(empty.Root()); root != want {
+		t.Errorf("Root() of the empty tree = %s, want %s", root, want)
+	}
+}
+
+// naiveRoot computes MTH of RFC 6962 section 2.1 recursively
+func naiveRoot(htype HashType, leaves [][]byte) []byte {
+	if len(leaves) == 1 {
+		leaf, _ := MerkleLeafHash(htype, leaves[0])
+		return leaf
+	}
+	k := splitSize(len(leaves))
+	return merkleNodeHash(htype, naiveRoot(htype, leaves[:k]), naiveRoot(htype, leaves[k:]))
+}
+
+func testLeaves(n int) [][]byte {
+	leaves := make([][]byte, n)
+	for i := range leaves {
+		leaves[i] = []byte(fmt.Sprintf("chunk %d", i))
+	}
+	return leaves
+}
+
+func TestMerkleParallel(t *testing.T) {
+	// large enough to hash the leaves and the first levels in parallel
+	leaves := testLeaves(3*merkleParallelThreshold + 17)
+	for _, htype := range []HashType{SHA256, SHA3_512} {
+		tree, err := NewMerkleTree(htype, leaves)
+		if err != nil {
+			t.Fatalf("NewMerkleTree() error = %v", err)
+		}
+		if hex.EncodeToString(tree.Root()) != hex.EncodeToString(naiveRoot(htype, leaves)) {
+			t.Errorf("Root() does not match the recursive definition")
+		}
+		proof, _ := tree.InclusionProof(2000)
+		if err := VerifyInclusion(htype, leaves[2000], 2000, tree.Size(), proof, tree.Root()); err != nil {
+			t.Errorf("VerifyInclusion() error = %v", err)
+		}
+	}
+	if _, err := NewMerkleTree(0, leaves); err != ErrInvalidHashType {
+		t.Errorf("NewMerkleTree() error = %v, want %v", err, ErrInvalidHashType)
+	}
+}
+
+func TestMerkleInclusionProof(t *testing.T) {
+	for n := 1; n <= 33; n++ {
+		leaves := testLeaves(n)
+		tree, _ := NewMerkleTree(SHA256, leaves)
+		root := tree.Root()
+		for i := 0; i < n; i++ {
+			proof, err := tree.InclusionProof(i)
+			if err != nil {
+				t.Fatalf("InclusionProof(%d) error = %v", i, err)
+			}
+			if err := VerifyInclusion(SHA256, leaves[i], i, n, proof, root); err != nil {
+				t.Errorf("VerifyInclusion(%d of %d) error = %v", i, n, err)
+			}
+			if err := VerifyInclusion(SHA256, []byte("forged"), i, n, proof, root); err != ErrInvalidProof {
+				t.Errorf("VerifyInclusion(%d of %d) of a forged leaf error = %v, want %v", i, n, err, ErrInvalidProof)
+			}
+			if n > 1 {
+				if err := VerifyInclusion(SHA256, leaves[i], (i+1)%n, n, proof, root); err != ErrInvalidProof {
+					t.Errorf("VerifyInclusion(%d of %d) at the wrong index error = %v, want %v", i, n, err, ErrInvalidProof)
+				}
+				if err := VerifyInclusion(SHA256, leaves[i], i, n, proof[:len(proof)-1], root); err != ErrInvalidProof {
+					t.Errorf("VerifyInclusion(%d of %d) with a short proof error = %v, want %v", i, n, err, ErrInvalidProof)
+				}
+			}
+		}
+		if _, err := tree.InclusionProof(n); err != ErrIndexOutOfRange {
+			t.Errorf("InclusionProof(%d) error = %v, want %v", n, err, ErrIndexOutOfRange)
+		}
+	}
+}
+
+func TestMerkleConsistencyProof(t *testing.T) {
+	leaves := testLeaves(33)
+	roots := make([][]byte, len(leaves)+1)
+	for n := 0; n <= len(leaves); n++ {
+		tree, _ := NewMerkleTree(SHA256, leaves[:n])
+		roots[n] = tree.Root()
+	}
+	for n := 1; n <= len(leaves); n++ {
+		tree, _ := NewMerkleTree(SHA256, leaves[:n])
+		for m := 0; m <= n; m++ {
+			proof, err := tree.ConsistencyProof(m)
+			if err != nil {
+				t.Fatalf("ConsistencyProof(%d) error = %v", m, err)
+			}
+			if err := VerifyConsistency(SHA256, m, n, roots[m], roots[n], proof); err != nil {
+				t.Errorf("VerifyConsistency(%d, %d) error = %v", m, n, err)
+			}
+			if m > 0 && m < n {
+				if err := VerifyConsistency(SHA256, m, n, roots[m-1], roots[n], proof); err != ErrInvalidProof {
+					t.Errorf("VerifyConsistency(%d, %d) with a wrong old root error = %v, want %v", m, n, err, ErrInvalidProof)
+				}
+				if err := VerifyConsistency(SHA256, m, n, roots[m], roots[n-1], proof); err != ErrInvalidProof {
+					t.Errorf("VerifyConsistency(%d, %d) with a wrong new root error = %v, want %v", m, n, err, ErrInvalidProof)
+				}
+			}
+		}
+		if _, err := tree.ConsistencyProof(n + 1); err != ErrIndexOutOfRange {
+			t.Errorf("ConsistencyProof(%d) error = %v, want %v", n+1, err, ErrIndexOutOfRange)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package hash
+
+// registry of hash algorithms by HashType and by name, so applications can add their own
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha3"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+	"sync"
+)
+
+// errors
+var (
+	// ErrDuplicateHashName indicates a name which is already registered
+	ErrDuplicateHashName = errors.New("hash name already registered")
+)
+
+type algorithm struct {
+	name    string
+	std     crypto.Hash // zero for algorithms without a standard crypto.Hash
+	newFunc func() hash.Hash
+}
+
+var (
+	registryMu sync.RWMutex
+	algorithms = map[HashType]algorithm{
+		SHA1:       {name: "sha1", std: crypto.SHA1, newFunc: sha1.New},
+		SHA256:     {name: "sha256", std: crypto.SHA256, newFunc: sha256.New},
+		SHA224:     {name: "sha224", std: crypto.SHA224, newFunc: sha256.New224},
+		SHA384:     {name: "sha384", std: crypto.SHA384, newFunc: sha512.New384},
+		SHA512:     {name: "sha512", std: crypto.SHA512, newFunc: sha512.New},
+		SHA512_224: {name: "sha512/224", std: crypto.SHA512_224, newFunc: sha512.New512_224},
+		SHA512_256: {name: "sha512/256", std: crypto.SHA512_256, newFunc: sha512.New512_256},
+		SHA3_224:   {name: "sha3-224", std: crypto.SHA3_224, newFunc: func() hash.Hash { return sha3.New224() }},
+		SHA3_256:   {name: "sha3-256", std: crypto.SHA3_256, newFunc: func() hash.Hash { return sha3.New256() }},
+		SHA3_384:   {name: "sha3-384", std: crypto.SHA3_384, newFunc: func() hash.Hash { return sha3.New384() }},
+		SHA3_512:   {name: "sha3-512", std: crypto.SHA3_512, newFunc: func() hash.Hash { return sha3.New512() }},
+		SHAKE128: {name: "shake128", newFunc: func() hash.Hash {
+			return &shakeHash{SHAKE: sha3.NewSHAKE128(), size: 32}
+		}},
+		SHAKE256: {name: "shake256", newFunc: func() hash.Hash {
+			return &shakeHash{SHAKE: sha3.NewSHAKE256(), size: 64}
+		}},
+	}
+	names = map[string]HashType{
+		// other common spelling
+		"sha512-224": SHA512_224,
+		"sha512-256": SHA512_256,
+	}
+	nextHashType = SHAKE256 + 1
+)
+
+func init() {
+	for htype, alg := range algorithms {
+		names[alg.name] = htype
+	}
+}
+
+// normalizeName accepts spellings like SHA-512/256, sha512_256 and SHA3-256
+func normalizeName(name string) string {
+	name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "_", "-")
+	return strings.Replace(name, "sha-", "sha", 1)
+}
+
+// RegisterOption configures an algorithm added with Register
+type RegisterOption func(*algorithm)
+
+// WithCryptoHash sets the standard crypto.Hash of the algorithm, which GetStdCryptoHash
+// returns. Without it the algorithm works for hashing and HMAC, but not for RSA
+// signatures or OAEP, which identify their hash by crypto.Hash.
+// Register fails unless the constructor computes the same digests as std.
+func WithCryptoHash(std crypto.Hash) RegisterOption {
+	return func(alg *algorithm) {
+		alg.std = std
+	}
+}
+
+// Register adds a hash algorithm under name and returns its new HashType,
+// after which New, String and ParseHashType work with it like with the built-in ones
+func Register(name string, newFunc func() hash.Hash, opts ...RegisterOption) (HashType, error) {
+	name = normalizeName(name)
+	if name == "" || newFunc == nil {
+		return 0, ErrInvalidHashType
+	}
+	alg := algorithm{name: name, newFunc: newFunc}
+	for _, opt := range opts {
+		opt(&alg)
+	}
+	if alg.std != 0 && (!alg.std.Available() || !sameDigests(alg.std.New, newFunc)) {
+		return 0, ErrInvalidHashType
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := names[name]; ok {
+		return 0, ErrDuplicateHashName
+	}
+	htype := nextHashType
+	nextHashType++
+	algorithms[htype] = alg
+	names[name] = htype
+	return htype, nil
+}
+
+// Unregister removes an algorithm added with Register, the built-in algorithms cannot be removed
+func Unregister(htype HashType) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	alg, ok := algorithms[htype]
+	if !ok || htype <= SHAKE256 {
+		return ErrInvalidHashType
+	}
+	delete(names, alg.name)
+	delete(algorithms, htype)
+	return nil
+}
+
+// sameDigests reports whether two constructors hash the empty input and a test input alike
+func sameDigests(a, b func() hash.Hash) bool {
+	ha, hb := a(), b()
+	if ha.Size() != hb.Size() || !bytes.Equal(ha.Sum(nil), hb.Sum(nil)) {
+		return false
+	}
+	ha.Write([]byte("github.com/priyanshujain/crypto"))
+	hb.Write([]byte("github.com/priyanshujain/crypto"))
+	return bytes.Equal(ha.Sum(nil), hb.Sum(nil))
+}
+
+func lookup(htype HashType) (algorithm, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	alg, ok := algorithms[htype]
+	return alg, ok
+}
+
+// ParseHashType returns the HashType of a registered name, case insensitive
+func ParseHashType(name string) (HashType, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	htype, ok := names[normalizeName(name)]
+	if !ok {
+		return 0, ErrInvalidHashType
+	}
+	return htype, nil
+}
+
+// String returns the registered name like "sha256" or "sha3-512"
+func (htype HashType) String() string {
+	alg, ok := lookup(htype)
+	if !ok {
+		return fmt.Sprintf("HashType(%d)", uint(htype))
+	}
+	return alg.name
+}
+
+// MarshalText encodes the name, the zero HashType is encoded as an empty string
+func (htype HashType) MarshalText() ([]byte, error) {
+	if htype == 0 {
+		return []byte{}, nil
+	}
+	alg, ok := lookup(htype)
+	if !ok {
+		return nil, ErrInvalidHashType
+	}
+	return []byte(alg.name), nil
+}
+
+// UnmarshalText decodes a name with ParseHashType, an empty string is the zero HashType
+func (htype *HashType) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*htype = 0
+		return nil
+	}
+	parsed, err := ParseHashType(string(text))
+	if err != nil {
+		return err
+	}
+	*htype = parsed
+	return nil
+}
+
+// NewFunc returns the constructor of a hash algorithm, as needed by hmac.New
+func NewFunc(htype HashType) (func() hash.Hash, error) {
+	alg, ok := lookup(htype)
+	if !ok {
+		return nil, ErrInvalidHashType
+	}
+	return alg.newFunc, nil
+}
@@ -0,0 +1,178 @@
+package hash
+
+import (
+	"crypto"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"hash/fnv"
+	"testing"
+)
+
+// register adds an algorithm to the global registry for the duration of the test
+func register(t *testing.T, name string, newFunc func() hash.Hash, opts ...RegisterOption) HashType {
+	t.Helper()
+	htype, err := Register(name, newFunc, opts...)
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	t.Cleanup(func() {
+		if err := Unregister(htype); err != nil {
+			t.Errorf("Unregister() error = %v", err)
+		}
+	})
+	return htype
+}
+
+func TestHashTypeString(t *testing.T) {
+	for htype := SHA1; htype <= SHAKE256; htype++ {
+		parsed, err := ParseHashType(htype.String())
+		if err != nil {
+			t.Fatalf("ParseHashType(%q) error = %v", htype.String(), err)
+		}
+		if parsed != htype {
+			t.Errorf("ParseHashType(%q) = %d, want %d", htype.String(), parsed, htype)
+		}
+	}
+	if s := HashType(0).String(); s != "HashType(0)" {
+		t.Errorf("String() = %q, want %q", s, "HashType(0)")
+	}
+}
+
+func TestParseHashType(t *testing.T) {
+	tests := []struct {
+		name  string
+		htype HashType
+	}{
+		{"sha256", SHA256},
+		{"SHA-256", SHA256},
+		{" SHA256 ", SHA256},
+		{"SHA-512/256", SHA512_256},
+		{"sha512_256", SHA512_256},
+		{"SHA512-224", SHA512_224},
+		{"SHA3-384", SHA3_384},
+		{"sha3_512", SHA3_512},
+		{"SHAKE256", SHAKE256},
+	}
+	for _, tt := range tests {
+		htype, err := ParseHashType(tt.name)
+		if err != nil {
+			t.Errorf("ParseHashType(%q) error = %v", tt.name, err)
+			continue
+		}
+		if htype != tt.htype {
+			t.Errorf("ParseHashType(%q) = %v, want %v", tt.name, htype, tt.htype)
+		}
+	}
+	for _, name := range []string{"", "md5", "sha257"} {
+		if _, err := ParseHashType(name); err != ErrInvalidHashType {
+			t.Errorf("ParseHashType(%q) error = %v, want %v", name, err, ErrInvalidHashType)
+		}
+	}
+}
+
+func TestHashTypeJSON(t *testing.T) {
+	type config struct {
+		Hash     HashType            `json:"hash"`
+		Fallback HashType            `json:"fallback"`
+		Sizes    map[HashType]string `json:"sizes"`
+	}
+	in := config{Hash: SHA3_256, Sizes: map[HashType]string{SHA512_256: "32"}}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	want := `{"hash":"sha3-256","fallback":"","sizes":{"sha512/256":"32"}}`
+	if string(b) != want {
+		t.Errorf("json.Marshal() = %s, want %s", b, want)
+	}
+
+	var out config
+	if err := json.Unmarshal([]byte(`{"hash":"SHA-384","sizes":{"sha1":"20"}}`), &out); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if out.Hash != SHA384 || out.Fallback != 0 || out.Sizes[SHA1] != "20" {
+		t.Errorf("json.Unmarshal() = %+v", out)
+	}
+	if err := json.Unmarshal([]byte(`{"hash":"md5"}`), &out); err == nil {
+		t.Errorf("json.Unmarshal() of an unknown name succeeded")
+	}
+	if _, err := json.Marshal(config{Hash: 1000}); err == nil {
+		t.Errorf("json.Marshal() of an unknown HashType succeeded")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	htype := register(t, "FNV-1a-64", func() hash.Hash { return fnv.New64a() })
+	if htype.String() != "fnv-1a-64" {
+		t.Errorf("String() = %q, want %q", htype.String(), "fnv-1a-64")
+	}
+	if parsed, _ := ParseHashType("fnv_1a_64"); parsed != htype {
+		t.Errorf("ParseHashType() = %v, want %v", parsed, htype)
+	}
+	// https://datatracker.ietf.org/doc/html/draft-eastlake-fnv section 8
+	dst, err := Hash(htype, []byte("a"))
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if got := hex.EncodeToString(dst); got != "af63dc4c8601ec8c" {
+		t.Errorf("Hash() = %s, want %s", got, "af63dc4c8601ec8c")
+	}
+	if _, err := GetStdCryptoHash(htype); err != ErrInvalidHashType {
+		t.Errorf("GetStdCryptoHash() error = %v, want %v", err, ErrInvalidHashType)
+	}
+
+	if _, err := Register("fnv-1a-64", func() hash.Hash { return fnv.New64a() }); err != ErrDuplicateHashName {
+		t.Errorf("Register() of a registered name error = %v, want %v", err, ErrDuplicateHashName)
+	}
+	if _, err := Register("SHA-256", func() hash.Hash { return fnv.New64a() }); err != ErrDuplicateHashName {
+		t.Errorf("Register() of a built-in name error = %v, want %v", err, ErrDuplicateHashName)
+	}
+	if _, err := Register("nil", nil); err != ErrInvalidHashType {
+		t.Errorf("Register() without constructor error = %v, want %v", err, ErrInvalidHashType)
+	}
+}
+
+func TestUnregister(t *testing.T) {
+	htype, err := Register("fnv-1a-32", func() hash.Hash { return fnv.New32a() })
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := Unregister(htype); err != nil {
+		t.Fatalf("Unregister() error = %v", err)
+	}
+	if _, err := ParseHashType("fnv-1a-32"); err != ErrInvalidHashType {
+		t.Errorf("ParseHashType() error = %v, want %v", err, ErrInvalidHashType)
+	}
+	if _, err := New(htype); err != ErrInvalidHashType {
+		t.Errorf("New() error = %v, want %v", err, ErrInvalidHashType)
+	}
+	for _, htype := range []HashType{htype, SHA256, SHAKE256, 0} {
+		if err := Unregister(htype); err != ErrInvalidHashType {
+			t.Errorf("Unregister(%v) error = %v, want %v", htype, err, ErrInvalidHashType)
+		}
+	}
+}
+
+func TestRegisterCryptoHash(t *testing.T) {
+	htype := register(t, "md5", md5.New, WithCryptoHash(crypto.MD5))
+	std, err := GetStdCryptoHash(htype)
+	if err != nil || std != crypto.MD5 {
+		t.Errorf("GetStdCryptoHash() = %v, %v, want %v", std, err, crypto.MD5)
+	}
+	if _, err := Register("md5-fnv", func() hash.Hash { return fnv.New64a() }, WithCryptoHash(crypto.MD5)); err != ErrInvalidHashType {
+		t.Errorf("Register() with a crypto.Hash of another size error = %v, want %v", err, ErrInvalidHashType)
+	}
+	if std, _ := GetStdCryptoHash(register(t, "sha1-copy", sha1.New, WithCryptoHash(crypto.SHA1))); std != crypto.SHA1 {
+		t.Errorf("GetStdCryptoHash() = %v, want %v", std, crypto.SHA1)
+	}
+	if _, err := Register("sha256-as-sha3", sha256.New, WithCryptoHash(crypto.SHA3_256)); err != ErrInvalidHashType {
+		t.Errorf("Register() with a crypto.Hash of other digests error = %v, want %v", err, ErrInvalidHashType)
+	}
+	if _, err := Register("md4", md5.New, WithCryptoHash(crypto.MD4)); err != ErrInvalidHashType {
+		t.Errorf("Register() with an unavailable crypto.Hash error = %v, want %v", err, ErrInvalidHashType)
+	}
+}
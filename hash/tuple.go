@@ -0,0 +1,127 @@
+package hash
+
+// unambiguous hashing of several fields. Concatenating fields is ambiguous, ("ab", "c") and
+// ("a", "bc") give the same bytes, so every field is type tagged and length prefixed.
+// TupleHash is the NIST SP 800-185 section 5 function for a tuple of byte strings.
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// type tags of EncodeTuple
+const (
+	tupleBytes  = 0x01
+	tupleString = 0x02
+	tupleInt    = 0x03
+	tupleUint   = 0x04
+	tupleList   = 0x05
+)
+
+// errors
+var (
+	// ErrUnsupportedTupleType indicates a tuple field of a type EncodeTuple does not encode
+	ErrUnsupportedTupleType = errors.New("unsupported tuple field type")
+)
+
+// TupleHash returns TupleHash128 or TupleHash256 for SHAKE128 or SHAKE256 of size bytes,
+// the customization string may be empty
+func TupleHash(htype HashType, tuple [][]byte, customization []byte, size int) ([]byte, error) {
+	// the output length is encoded in bits
+	if size < 0 || uint64(size) > math.MaxUint64/8 {
+		return nil, ErrInvalidOutputSize
+	}
+	xof, err := NewCSHAKE(htype, []byte("TupleHash"), customization)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range tuple {
+		xof.Write(EncodeString(field))
+	}
+	xof.Write(RightEncode(uint64(size) * 8))
+	out := make([]byte, size)
+	xof.Read(out)
+	return out, nil
+}
+
+// EncodeTuple encodes fields deterministically, each one as a type tag followed by
+//   - []byte and string: the bit length (EncodeString) and the bytes
+//   - signed and unsigned integers: 8 bytes big endian, signed and unsigned have different tags
+//   - []any, []string and [][]byte: the number of elements (LeftEncode) and the encoded elements
+func EncodeTuple(fields ...any) ([]byte, error) {
+	return appendTuple(nil, fields)
+}
+
+func appendTuple(dst []byte, fields []any) ([]byte, error) {
+	var err error
+	for _, field := range fields {
+		if dst, err = appendTupleField(dst, field); err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
+}
+
+func appendTupleField(dst []byte, field any) ([]byte, error) {
+	switch v := field.(type) {
+	case []byte:
+		return append(append(dst, tupleBytes), EncodeString(v)...), nil
+	case string:
+		return append(append(dst, tupleString), EncodeString([]byte(v))...), nil
+	case int:
+		return appendTupleInt(dst, int64(v)), nil
+	case int8:
+		return appendTupleInt(dst, int64(v)), nil
+	case int16:
+		return appendTupleInt(dst, int64(v)), nil
+	case int32:
+		return appendTupleInt(dst, int64(v)), nil
+	case int64:
+		return appendTupleInt(dst, v), nil
+	case uint:
+		return appendTupleUint(dst, uint64(v)), nil
+	case uint8:
+		return appendTupleUint(dst, uint64(v)), nil
+	case uint16:
+		return appendTupleUint(dst, uint64(v)), nil
+	case uint32:
+		return appendTupleUint(dst, uint64(v)), nil
+	case uint64:
+		return appendTupleUint(dst, v), nil
+	case []any:
+		dst = append(append(dst, tupleList), LeftEncode(uint64(len(v)))...)
+		return appendTuple(dst, v)
+	case []string:
+		dst = append(append(dst, tupleList), LeftEncode(uint64(len(v)))...)
+		for _, s := range v {
+			dst = append(append(dst, tupleString), EncodeString([]byte(s))...)
+		}
+		return dst, nil
+	case [][]byte:
+		dst = append(append(dst, tupleList), LeftEncode(uint64(len(v)))...)
+		for _, b := range v {
+			dst = append(append(dst, tupleBytes), EncodeString(b)...)
+		}
+		return dst, nil
+	default:
+		return nil, ErrUnsupportedTupleType
+	}
+}
+
+func appendTupleInt(dst []byte, v int64) []byte {
+	return binary.BigEndian.AppendUint64(append(dst, tupleInt), uint64(v))
+}
+
+func appendTupleUint(dst []byte, v uint64) []byte {
+	return binary.BigEndian.AppendUint64(append(dst, tupleUint), v)
+}
+
+// HashTuple hashes the EncodeTuple encoding of fields
+func HashTuple(htype HashType, fields ...any) ([]byte, error) {
+	encoded, err := EncodeTuple(fields...)
+	if err != nil {
+		return nil, err
+	}
+	return Hash(htype, encoded)
+}
@@ -0,0 +1,119 @@
+package hash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math"
+	"testing"
+)
+
+// NIST SP 800-185 TupleHash samples
+// https://csrc.nist.gov/projects/cryptographic-standards-and-guidelines/example-values
+func TestTupleHash(t *testing.T) {
+	x := [][]byte{
+		{0x00, 0x01, 0x02},
+		{0x10, 0x11, 0x12, 0x13, 0x14, 0x15},
+		{0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28},
+	}
+	tests := []struct {
+		name          string
+		htype         HashType
+		tuple         [][]byte
+		customization string
+		size          int
+		out           string
+	}{
+		{"TupleHash128#1", SHAKE128, x[:2], "", 32, "c5d8786c1afb9b82111ab34b65b2c0048fa64e6d48e263264ce1707d3ffc8ed1"},
+		{"TupleHash128#2", SHAKE128, x[:2], "My Tuple App", 32, "75cdb20ff4db1154e841d758e24160c54bae86eb8c13e7f5f40eb35588e96dfb"},
+		{"TupleHash128#3", SHAKE128, x, "My Tuple App", 32, "e60f202c89a2631eda8d4c588ca5fd07f39e5151998deccf973adb3804bb6e84"},
+		{"TupleHash256#1", SHAKE256, x[:2], "", 64, "cfb7058caca5e668f81a12a20a2195ce97a925f1dba3e7449a56f82201ec607311ac2696b1ab5ea2352df1423bde7bd4bb78c9aed1a853c78672f9eb23bbe194"},
+	}
+	for _, tt := range tests {
+		out, err := TupleHash(tt.htype, tt.tuple, []byte(tt.customization), tt.size)
+		if err != nil {
+			t.Fatalf("%s: TupleHash() error = %v", tt.name, err)
+		}
+		if got := hex.EncodeToString(out); got != tt.out {
+			t.Errorf("%s: TupleHash() = %s, want %s", tt.name, got, tt.out)
+		}
+	}
+	if _, err := TupleHash(SHA256, x, nil, 32); err != ErrInvalidHashType {
+		t.Errorf("TupleHash() error = %v, want %v", err, ErrInvalidHashType)
+	}
+	sizes := []int{-1}
+	if math.MaxInt > math.MaxUint64/8 {
+		sizes = append(sizes, math.MaxInt)
+	}
+	for _, size := range sizes {
+		if _, err := TupleHash(SHAKE128, x, nil, size); err != ErrInvalidOutputSize {
+			t.Errorf("TupleHash(%d) error = %v, want %v", size, err, ErrInvalidOutputSize)
+		}
+	}
+	// the boundaries between fields are part of the hash
+	a, _ := TupleHash(SHAKE128, [][]byte{[]byte("ab"), []byte("c")}, nil, 32)
+	b, _ := TupleHash(SHAKE128, [][]byte{[]byte("a"), []byte("bc")}, nil, 32)
+	if bytes.Equal(a, b) {
+		t.Errorf("TupleHash() of shifted fields is equal")
+	}
+}
+
+func TestEncodeTuple(t *testing.T) {
+	encoded, err := EncodeTuple("ab", []byte{0xff}, -1, uint8(2), []any{"c", []string{}}, [][]byte{{}})
+	if err != nil {
+		t.Fatalf("EncodeTuple() error = %v", err)
+	}
+	want := "02" + "0110" + "6162" + // string "ab"
+		"01" + "0108" + "ff" + // bytes ff
+		"03" + "ffffffffffffffff" + // int -1
+		"04" + "0000000000000002" + // uint 2
+		"05" + "0102" + "02" + "0108" + "63" + "05" + "0100" + // list ("c", [])
+		"05" + "0101" + "01" + "0100" // list of one empty byte string
+	if got := hex.EncodeToString(encoded); got != want {
+		t.Errorf("EncodeTuple() = %s, want %s", got, want)
+	}
+	if _, err := EncodeTuple(1.5); err != ErrUnsupportedTupleType {
+		t.Errorf("EncodeTuple() of a float error = %v, want %v", err, ErrUnsupportedTupleType)
+	}
+	if _, err := EncodeTuple([]any{"a", struct{}{}}); err != ErrUnsupportedTupleType {
+		t.Errorf("EncodeTuple() of a nested struct error = %v, want %v", err, ErrUnsupportedTupleType)
+	}
+}
+
+func TestHashTupleUnambiguous(t *testing.T) {
+	tuples := [][]any{
+		{"ab", "c"},
+		{"a", "bc"},
+		{"abc"},
+		{[]byte("ab"), "c"},
+		{[]any{"ab", "c"}},
+		{[]string{"ab", "c"}, []any{}},
+		{[]any{"ab"}, "c"},
+		{1},
+		{uint(1)},
+		{int8(1), int16(0)},
+		{},
+	}
+	seen := map[string]int{}
+	for i, tuple := range tuples {
+		sum, err := HashTuple(SHA256, tuple...)
+		if err != nil {
+			t.Fatalf("HashTuple(%v) error = %v", tuple, err)
+		}
+		if j, ok := seen[string(sum)]; ok {
+			t.Errorf("HashTuple(%v) = HashTuple(%v)", tuple, tuples[j])
+		}
+		seen[string(sum)] = i
+	}
+	// the Go integer type does not matter, only signed or unsigned
+	a, _ := HashTuple(SHA256, int8(-5), uint16(7))
+	b, _ := HashTuple(SHA256, int64(-5), uint64(7))
+	if !bytes.Equal(a, b) {
+		t.Errorf("HashTuple() depends on the integer size")
+	}
+	// []string is the same list as []any of strings
+	a, _ = HashTuple(SHA256, []string{"x", "y"})
+	b, _ = HashTuple(SHA256, []any{"x", "y"})
+	if !bytes.Equal(a, b) {
+		t.Errorf("HashTuple() of []string and []any differ")
+	}
+}
@@ -0,0 +1,122 @@
+package hash
+
+// extendable-output functions (FIPS 202) and the encodings of NIST SP 800-185
+
+import (
+	"crypto/sha3"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/bits"
+)
+
+// errors
+var (
+	// ErrInvalidOutputSize indicates a negative XOF output size, or one too large to encode in bits
+	ErrInvalidOutputSize = errors.New("invalid output size")
+)
+
+// XOF is an extendable-output function, any amount of output is read after the input is written
+type XOF interface {
+	io.Writer
+	io.Reader
+	BlockSize() int
+	Reset()
+}
+
+// NewXOF returns SHAKE128 or SHAKE256
+func NewXOF(htype HashType) (XOF, error) {
+	switch htype {
+	case SHAKE128:
+		return sha3.NewSHAKE128(), nil
+	case SHAKE256:
+		return sha3.NewSHAKE256(), nil
+	default:
+		return nil, ErrInvalidHashType
+	}
+}
+
+// NewCSHAKE returns cSHAKE128 or cSHAKE256 (NIST SP 800-185) for SHAKE128 or SHAKE256,
+// with the function name and the customization string. With both empty it is plain SHAKE.
+func NewCSHAKE(htype HashType, functionName, customization []byte) (XOF, error) {
+	switch htype {
+	case SHAKE128:
+		return sha3.NewCSHAKE128(functionName, customization), nil
+	case SHAKE256:
+		return sha3.NewCSHAKE256(functionName, customization), nil
+	default:
+		return nil, ErrInvalidHashType
+	}
+}
+
+// HashXOF hashes src with SHAKE128 or SHAKE256 into size bytes
+func HashXOF(htype HashType, src []byte, size int) ([]byte, error) {
+	if size < 0 {
+		return nil, ErrInvalidOutputSize
+	}
+	switch htype {
+	case SHAKE128:
+		return sha3.SumSHAKE128(src, size), nil
+	case SHAKE256:
+		return sha3.SumSHAKE256(src, size), nil
+	default:
+		return nil, ErrInvalidHashType
+	}
+}
+
+// shakeHash is a SHAKE with a fixed output size, so it can be used as a hash.Hash
+type shakeHash struct {
+	*sha3.SHAKE
+	size int
+}
+
+func (h *shakeHash) Size() int {
+	return h.size
+}
+
+// Sum appends the output to b without changing the state, like other hashes do
+func (h *shakeHash) Sum(b []byte) []byte {
+	state, err := h.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+	out := make([]byte, h.size)
+	h.Read(out)
+	if err := h.UnmarshalBinary(state); err != nil {
+		panic(err)
+	}
+	return append(b, out...)
+}
+
+// LeftEncode encodes x with its length in bytes prepended (NIST SP 800-185 section 2.3.1)
+func LeftEncode(x uint64) []byte {
+	n := (bits.Len64(x) + 7) / 8
+	if n == 0 {
+		n = 1
+	}
+	var b [9]byte
+	binary.BigEndian.PutUint64(b[1:], x)
+	b[8-n] = byte(n)
+	return append([]byte{}, b[8-n:]...)
+}
+
+// RightEncode encodes x with its length in bytes appended (NIST SP 800-185 section 2.3.1)
+func RightEncode(x uint64) []byte {
+	encoded := LeftEncode(x)
+	return append(encoded[1:], encoded[0])
+}
+
+// EncodeString encodes s with its bit length prepended (NIST SP 800-185 section 2.3.2)
+func EncodeString(s []byte) []byte {
+	return append(LeftEncode(uint64(len(s))*8), s...)
+}
+
+// BytePad prepends the encoding of w to x and pads the result with zeros to a multiple
+// of w bytes (NIST SP 800-185 section 2.3.3)
+func BytePad(x []byte, w int) []byte {
+	padded := append(LeftEncode(uint64(w)), x...)
+	if rem := len(padded) % w; rem != 0 {
+		padded = append(padded, make([]byte, w-rem)...)
+	}
+	return padded
+}
@@ -0,0 +1,128 @@
+package hash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+func sequence(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i)
+	}
+	return b
+}
+
+// NIST SP 800-185 cSHAKE samples
+// https://csrc.nist.gov/CSRC/media/Projects/Cryptographic-Standards-and-Guidelines/documents/examples/cSHAKE_samples.pdf
+var cshakeTestCases = []struct {
+	htype         HashType
+	data          []byte
+	customization string
+	out           string
+}{
+	{
+		htype:         SHAKE128,
+		data:          sequence(4),
+		customization: "Email Signature",
+		out:           "c1c36925b6409a04f1b504fcbca9d82b4017277cb5ed2b2065fc1d3814d5aaf5",
+	},
+	{
+		htype:         SHAKE128,
+		data:          sequence(200),
+		customization: "Email Signature",
+		out:           "c5221d50e4f822d96a2e8881a961420f294b7b24fe3d2094baed2c6524cc166b",
+	},
+}
+
+func TestCSHAKE(t *testing.T) {
+	for i, test := range cshakeTestCases {
+		t.Run(fmt.Sprintf("cSHAKE: sample %d", i+1), func(t *testing.T) {
+			h, err := NewCSHAKE(test.htype, nil, []byte(test.customization))
+			if err != nil {
+				t.Fatalf("NewCSHAKE() error = %v", err)
+			}
+			h.Write(test.data)
+			out := make([]byte, len(test.out)/2)
+			h.Read(out)
+			if hex.EncodeToString(out) != test.out {
+				t.Errorf("cSHAKE = %x, want %s", out, test.out)
+			}
+		})
+	}
+	if _, err := NewCSHAKE(SHA3_256, nil, nil); err != ErrInvalidHashType {
+		t.Errorf("NewCSHAKE() error = %v, want %v", err, ErrInvalidHashType)
+	}
+}
+
+func TestXOF(t *testing.T) {
+	for _, htype := range []HashType{SHAKE128, SHAKE256} {
+		t.Run(fmt.Sprintf("XOF: %d", htype), func(t *testing.T) {
+			long, err := HashXOF(htype, []byte("abc"), 200)
+			if err != nil {
+				t.Fatalf("HashXOF() error = %v", err)
+			}
+			// the output of Hash is a prefix of the extendable output
+			digest, _ := Hash(htype, []byte("abc"))
+			if !bytes.HasPrefix(long, digest) {
+				t.Errorf("Hash() = %x is not a prefix of HashXOF() = %x", digest, long)
+			}
+
+			// reading in pieces gives the same output
+			h, _ := NewXOF(htype)
+			h.Write([]byte("a"))
+			h.Write([]byte("bc"))
+			out := make([]byte, 200)
+			h.Read(out[:7])
+			h.Read(out[7:])
+			if !bytes.Equal(out, long) {
+				t.Errorf("XOF output = %x, want %x", out, long)
+			}
+
+			// Sum does not change the state
+			hh, _ := New(htype)
+			hh.Write([]byte("ab"))
+			hh.Sum(nil)
+			hh.Write([]byte("c"))
+			if sum := hh.Sum(nil); !bytes.Equal(sum, digest) || len(sum) != hh.Size() {
+				t.Errorf("Sum() = %x, want %x", sum, digest)
+			}
+		})
+	}
+	if _, err := NewXOF(SHA256); err != ErrInvalidHashType {
+		t.Errorf("NewXOF() error = %v, want %v", err, ErrInvalidHashType)
+	}
+	if _, err := GetStdCryptoHash(SHAKE128); err != ErrInvalidHashType {
+		t.Errorf("GetStdCryptoHash() error = %v, want %v", err, ErrInvalidHashType)
+	}
+	if _, err := HashXOF(SHAKE128, []byte("abc"), -1); err != ErrInvalidOutputSize {
+		t.Errorf("HashXOF() error = %v, want %v", err, ErrInvalidOutputSize)
+	}
+	if out, err := HashXOF(SHAKE256, []byte("abc"), 0); err != nil || len(out) != 0 {
+		t.Errorf("HashXOF() = %x, %v, want empty", out, err)
+	}
+}
+
+func TestSP800185Encodings(t *testing.T) {
+	tests := []struct {
+		got  []byte
+		want string
+	}{
+		{LeftEncode(0), "0100"},
+		{LeftEncode(168), "01a8"},
+		{LeftEncode(256), "020100"},
+		{RightEncode(0), "0001"},
+		{RightEncode(512), "020002"},
+		{EncodeString(nil), "0100"},
+		{EncodeString([]byte("KMAC")), "01204b4d4143"},
+		{BytePad([]byte{0xff}, 4), "0104ff00"},
+		{BytePad([]byte{0xff, 0xff}, 4), "0104ffff"},
+	}
+	for _, test := range tests {
+		if hex.EncodeToString(test.got) != test.want {
+			t.Errorf("encoding = %x, want %s", test.got, test.want)
+		}
+	}
+}
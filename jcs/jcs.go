@@ -0,0 +1,273 @@
+// jcs package implements the JSON Canonicalization Scheme
+// https://datatracker.ietf.org/doc/html/rfc8785
+// Object members are sorted by the UTF-16 code units of their names, numbers are written
+// like ECMAScript Number.prototype.toString and strings with the minimal escaping, so equal
+// JSON values from different producers have the same bytes to hash or sign.
+package jcs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/priyanshujain/crypto/hash"
+)
+
+// errors
+var (
+	// ErrInvalidJSON indicates input which is not a single valid JSON value in UTF-8
+	ErrInvalidJSON = errors.New("jcs: invalid json")
+
+	// ErrDuplicateKey indicates an object with the same member name twice
+	ErrDuplicateKey = errors.New("jcs: duplicate object key")
+
+	// ErrInvalidNumber indicates a number which is not a finite IEEE 754 double
+	ErrInvalidNumber = errors.New("jcs: number out of range")
+)
+
+// Canonicalize returns the canonical form of a JSON document
+func Canonicalize(data []byte) ([]byte, error) {
+	if !utf8.Valid(data) {
+		return nil, ErrInvalidJSON
+	}
+	if err := checkSurrogates(data); err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var b bytes.Buffer
+	if err := canonicalizeValue(dec, &b); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, ErrInvalidJSON
+	}
+	return b.Bytes(), nil
+}
+
+// checkSurrogates rejects strings with an unpaired \uD800-\uDFFF escape, which I-JSON
+// (RFC 7493) does not allow and encoding/json would replace by U+FFFD, so "\ud800" and
+// "\ufffd" would canonicalize to the same bytes. Other errors are left to the decoder.
+func checkSurrogates(data []byte) error {
+	inString := false
+	for i := 0; i < len(data); i++ {
+		switch {
+		case !inString:
+			inString = data[i] == '"'
+		case data[i] == '"':
+			inString = false
+		case data[i] == '\\':
+			i++
+			r, ok := unicodeEscape(data, i)
+			if !ok {
+				continue
+			}
+			i += 4
+			switch {
+			case r >= 0xd800 && r < 0xdc00:
+				low, ok := unicodeEscape(data, i+2)
+				if !ok || data[i+1] != '\\' || low < 0xdc00 || low > 0xdfff {
+					return fmt.Errorf("%w: unpaired surrogate", ErrInvalidJSON)
+				}
+				i += 6
+			case r >= 0xdc00 && r <= 0xdfff:
+				return fmt.Errorf("%w: unpaired surrogate", ErrInvalidJSON)
+			}
+		}
+	}
+	return nil
+}
+
+// unicodeEscape decodes the code unit of a uXXXX escape at data[i:]
+func unicodeEscape(data []byte, i int) (rune, bool) {
+	if i+5 > len(data) || data[i] != 'u' {
+		return 0, false
+	}
+	r, err := strconv.ParseUint(string(data[i+1:i+5]), 16, 16)
+	if err != nil {
+		return 0, false
+	}
+	return rune(r), true
+}
+
+// Marshal encodes v with encoding/json and canonicalizes the result
+func Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return Canonicalize(data)
+}
+
+// Hash hashes the canonical form of a JSON document
+func Hash(htype hash.HashType, data []byte) ([]byte, error) {
+	canonical, err := Canonicalize(data)
+	if err != nil {
+		return nil, err
+	}
+	return hash.Hash(htype, canonical)
+}
+
+func canonicalizeValue(dec *json.Decoder, b *bytes.Buffer) error {
+	token, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+	}
+	switch v := token.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			return canonicalizeObject(dec, b)
+		case '[':
+			return canonicalizeArray(dec, b)
+		default:
+			return ErrInvalidJSON
+		}
+	case string:
+		writeString(b, v)
+	case json.Number:
+		s, err := formatNumber(string(v))
+		if err != nil {
+			return err
+		}
+		b.WriteString(s)
+	case bool:
+		b.WriteString(strconv.FormatBool(v))
+	case nil:
+		b.WriteString("null")
+	}
+	return nil
+}
+
+type member struct {
+	name  string
+	key   []uint16 // sort key
+	value []byte
+}
+
+func canonicalizeObject(dec *json.Decoder, b *bytes.Buffer) error {
+	var members []member
+	seen := map[string]bool{}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+		}
+		name := token.(string)
+		if seen[name] {
+			return fmt.Errorf("%w: %q", ErrDuplicateKey, name)
+		}
+		seen[name] = true
+		var value bytes.Buffer
+		if err := canonicalizeValue(dec, &value); err != nil {
+			return err
+		}
+		members = append(members, member{name: name, key: utf16.Encode([]rune(name)), value: value.Bytes()})
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+	}
+	slices.SortFunc(members, func(a, b member) int {
+		return slices.Compare(a.key, b.key)
+	})
+	b.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		writeString(b, m.name)
+		b.WriteByte(':')
+		b.Write(m.value)
+	}
+	b.WriteByte('}')
+	return nil
+}
+
+func canonicalizeArray(dec *json.Decoder, b *bytes.Buffer) error {
+	b.WriteByte('[')
+	for i := 0; dec.More(); i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if err := canonicalizeValue(dec, b); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+	}
+	b.WriteByte(']')
+	return nil
+}
+
+// writeString escapes only quotes, backslashes and control characters (RFC 8785 section 3.2.2.2)
+func writeString(b *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if c < 0x20 {
+				b.WriteString(`\u00`)
+				b.WriteByte(hex[c>>4])
+				b.WriteByte(hex[c&0xf])
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+}
+
+// formatNumber writes a number like ECMAScript Number.prototype.toString (RFC 8785 section 3.2.2.3)
+func formatNumber(s string) (string, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", fmt.Errorf("%w: %s", ErrInvalidNumber, s)
+	}
+	return FormatNumber(f)
+}
+
+// FormatNumber returns the ECMAScript string of a finite number, -0 is "0"
+func FormatNumber(f float64) (string, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", ErrInvalidNumber
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+	s := strconv.FormatFloat(f, format, -1, 64)
+	if format == 'e' {
+		// ECMAScript writes 1e-7 where Go writes 1e-07
+		if n := len(s); n >= 4 && s[n-4] == 'e' && s[n-2] == '0' {
+			s = s[:n-2] + s[n-1:]
+		}
+	}
+	return s, nil
+}
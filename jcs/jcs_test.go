@@ -0,0 +1,260 @@
+package jcs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/priyanshujain/crypto/hash"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		out  string
+	}{
+		{
+			// RFC 8785 section 3.2.2
+			name: "RFC 8785 3.2.2",
+			in: `{
+				"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+				"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+				"literals": [null, true, false]
+			}`,
+			out: `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		},
+		{
+			// RFC 8785 section 3.2.3, names are sorted by UTF-16 code units
+			name: "RFC 8785 3.2.3",
+			in: `{
+				"\u20ac": "Euro Sign",
+				"\r": "Carriage Return",
+				"\ufb33": "Hebrew Letter Dalet With Dagesh",
+				"1": "One",
+				"\ud83d\ude00": "Emoji: Grinning Face",
+				"\u0080": "Control",
+				"\u00f6": "Latin Small Letter O With Diaeresis"
+			}`,
+			out: "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"\u00f6\":\"Latin Small Letter O With Diaeresis\",\"\u20ac\":\"Euro Sign\",\"\U0001f600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}",
+		},
+		{
+			name: "nested",
+			in:   ` { "b" : [ { "d" : 1 , "c" : -0 } , [ ] , { } ] , "a" : "\u2028\b\t\f\u001f" } `,
+			out:  `{"a":"` + "\u2028" + `\b\t\f\u001f","b":[{"c":0,"d":1},[],{}]}`,
+		},
+		{
+			name: "scalar",
+			in:   `  1.0E2 `,
+			out:  `100`,
+		},
+	}
+	for _, tt := range tests {
+		out, err := Canonicalize([]byte(tt.in))
+		if err != nil {
+			t.Errorf("%s: Canonicalize() error = %v", tt.name, err)
+			continue
+		}
+		if string(out) != tt.out {
+			t.Errorf("%s: Canonicalize() = %s, want %s", tt.name, out, tt.out)
+		}
+	}
+}
+
+// testdata/input and testdata/output are the reference files of RFC 8785
+// https://github.com/cyberphone/json-canonicalization/tree/master/testdata
+func TestCanonicalizeTestdata(t *testing.T) {
+	for _, name := range []string{"arrays", "french", "structures", "unicode", "values", "weird"} {
+		in, err := os.ReadFile(filepath.Join("testdata", "input", name+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(filepath.Join("testdata", "output", name+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := Canonicalize(in)
+		if err != nil {
+			t.Errorf("%s: Canonicalize() error = %v", name, err)
+			continue
+		}
+		if string(out) != string(want) {
+			t.Errorf("%s: Canonicalize() = %s, want %s", name, out, want)
+		}
+	}
+}
+
+// testdata/es6numbers.txt has lines "<ieee 754 hex>,<Number.prototype.toString()>" in the
+// format of the es6testfile100m.txt number file of RFC 8785, generated by testdata/numgen.js
+// with a fixed seed. With -short only the first 1000 numbers are checked.
+func TestFormatNumberES6(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "es6numbers.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	lines := 0
+	for scanner.Scan() {
+		if testing.Short() && lines == 1000 {
+			break
+		}
+		bits, want, ok := strings.Cut(scanner.Text(), ",")
+		if !ok {
+			t.Fatalf("invalid line %q", scanner.Text())
+		}
+		u, err := strconv.ParseUint(bits, 16, 64)
+		if err != nil {
+			t.Fatalf("invalid line %q", scanner.Text())
+		}
+		out, err := FormatNumber(math.Float64frombits(u))
+		if err != nil || out != want {
+			t.Errorf("FormatNumber(%s) = %s, %v, want %s", bits, out, err, want)
+		}
+		lines++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if lines == 0 {
+		t.Fatal("no numbers in testdata/es6numbers.txt")
+	}
+}
+
+// RFC 8785 appendix B, checked with Number.prototype.toString of Node.js
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		bits string
+		out  string
+	}{
+		{"0000000000000000", "0"},
+		{"8000000000000000", "0"},
+		{"0000000000000001", "5e-324"},
+		{"8000000000000001", "-5e-324"},
+		{"7fefffffffffffff", "1.7976931348623157e+308"},
+		{"ffefffffffffffff", "-1.7976931348623157e+308"},
+		{"4340000000000000", "9007199254740992"},
+		{"c340000000000000", "-9007199254740992"},
+		{"4430000000000000", "295147905179352830000"},
+		{"44b52d02c7e14af5", "9.999999999999997e+22"},
+		{"44b52d02c7e14af6", "1e+23"},
+		{"44b52d02c7e14af7", "1.0000000000000001e+23"},
+		{"444b1ae4d6e2ef4e", "999999999999999700000"},
+		{"444b1ae4d6e2ef4f", "999999999999999900000"},
+		{"444b1ae4d6e2ef50", "1e+21"},
+		{"3eb0c6f7a0b5ed8c", "9.999999999999997e-7"},
+		{"3eb0c6f7a0b5ed8d", "0.000001"},
+		{"41b3de4355555553", "333333333.3333332"},
+		{"41b3de4355555554", "333333333.33333325"},
+		{"41b3de4355555555", "333333333.3333333"},
+		{"41b3de4355555556", "333333333.3333334"},
+		{"41b3de4355555557", "333333333.33333343"},
+		{"becbf647612f3696", "-0.0000033333333333333333"},
+		{"43143ff3c1cb0959", "1424953923781206.2"},
+	}
+	for _, tt := range tests {
+		b, _ := hex.DecodeString(tt.bits)
+		f := math.Float64frombits(binary.BigEndian.Uint64(b))
+		out, err := FormatNumber(f)
+		if err != nil {
+			t.Errorf("FormatNumber(%s) error = %v", tt.bits, err)
+			continue
+		}
+		if out != tt.out {
+			t.Errorf("FormatNumber(%s) = %s, want %s", tt.bits, out, tt.out)
+		}
+	}
+	for _, bits := range []uint64{0x7fffffffffffffff, 0x7ff0000000000000, 0xfff0000000000000} {
+		if _, err := FormatNumber(math.Float64frombits(bits)); err != ErrInvalidNumber {
+			t.Errorf("FormatNumber(%x) error = %v, want %v", bits, err, ErrInvalidNumber)
+		}
+	}
+}
+
+func TestCanonicalizeErrors(t *testing.T) {
+	tests := []struct {
+		in  string
+		err error
+	}{
+		{`{"a":1,"a":2}`, ErrDuplicateKey},
+		{`[1e400]`, ErrInvalidNumber},
+		{`{"a":1`, ErrInvalidJSON},
+		{`{"a" 1}`, ErrInvalidJSON},
+		{`[1,]`, ErrInvalidJSON},
+		{`1 2`, ErrInvalidJSON},
+		{``, ErrInvalidJSON},
+		{"\"\xff\"", ErrInvalidJSON},
+		// unpaired surrogates are not I-JSON
+		{`"\ud800"`, ErrInvalidJSON},
+		{`"\udc00"`, ErrInvalidJSON},
+		{`"a\uD83Db"`, ErrInvalidJSON},
+		{`"\ud83d\u0041"`, ErrInvalidJSON},
+		{`"\ude00\ud83d"`, ErrInvalidJSON},
+		{`{"\ud800":1}`, ErrInvalidJSON},
+		{`["\ud83d\ude00", "\ud800"]`, ErrInvalidJSON},
+	}
+	for _, tt := range tests {
+		if _, err := Canonicalize([]byte(tt.in)); !errors.Is(err, tt.err) {
+			t.Errorf("Canonicalize(%q) error = %v, want %v", tt.in, err, tt.err)
+		}
+	}
+}
+
+func TestCanonicalizeSurrogates(t *testing.T) {
+	tests := []struct {
+		in  string
+		out string
+	}{
+		{`"\ud83d\ude00"`, "\"\U0001F600\""},
+		{`"\\ud800"`, `"\\ud800"`},
+		{`"\"\ud800"`, ""},
+		{`"\ufffd"`, "\"\ufffd\""},
+	}
+	for _, tt := range tests {
+		out, err := Canonicalize([]byte(tt.in))
+		if tt.out == "" {
+			if !errors.Is(err, ErrInvalidJSON) {
+				t.Errorf("Canonicalize(%s) error = %v, want %v", tt.in, err, ErrInvalidJSON)
+			}
+			continue
+		}
+		if err != nil || string(out) != tt.out {
+			t.Errorf("Canonicalize(%s) = %s, %v, want %s", tt.in, out, err, tt.out)
+		}
+	}
+}
+
+func TestMarshalAndHash(t *testing.T) {
+	type payment struct {
+		To     string  `json:"to"`
+		Amount float64 `json:"amount"`
+		Memo   string  `json:"memo"`
+	}
+	out, err := Marshal(payment{To: "alice", Amount: 10.50, Memo: "<rent>"})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	// encoding/json escapes < and >, which the canonical form does not
+	if want := `{"amount":10.5,"memo":"<rent>","to":"alice"}`; string(out) != want {
+		t.Errorf("Marshal() = %s, want %s", out, want)
+	}
+
+	a, err := Hash(hash.SHA256, []byte(`{"to":"alice","amount":10.50,"memo":"\u003crent>"}`))
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	b, _ := hash.Hash(hash.SHA256, out)
+	if hex.EncodeToString(a) != hex.EncodeToString(b) {
+		t.Errorf("Hash() differs from the hash of the canonical form")
+	}
+	if _, err := Hash(hash.SHA256, []byte(`{`)); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("Hash() error = %v, want %v", err, ErrInvalidJSON)
+	}
+}
@@ -0,0 +1,29 @@
+// Generates es6numbers.txt, lines of "<ieee 754 hex>,<Number.prototype.toString()>" in the
+// format of es6testfile100m.txt of RFC 8785 (https://github.com/cyberphone/json-canonicalization).
+// The doubles come from an xorshift64 generator with the fixed seed 0x9e3779b97f4a7c15, so the
+// output is the same on every run:
+//
+//	node numgen.js 10000 > es6numbers.txt
+const count = Number(process.argv[2] || 10000);
+let s = 0x9e3779b97f4a7c15n;
+const mask = (1n << 64n) - 1n;
+function next() {
+  s ^= (s << 13n) & mask; s ^= s >> 7n; s ^= (s << 17n) & mask;
+  return s;
+}
+const buf = new DataView(new ArrayBuffer(8));
+const lines = [];
+while (lines.length < count) {
+  let bits = next();
+  if (lines.length % 4 == 1) {
+    // integers and short decimals around the exponent switch at 1e21 and 1e-7
+    const e = Number(next() % 60n) - 30;
+    buf.setFloat64(0, Number(next() % 100000000n) * Math.pow(10, e));
+    bits = buf.getBigUint64(0);
+  }
+  buf.setBigUint64(0, bits);
+  const d = buf.getFloat64(0);
+  if (!isFinite(d)) continue;
+  lines.push(bits.toString(16) + ',' + d.toString());
+}
+process.stdout.write(lines.join('\n') + '\n');
@@ -0,0 +1,109 @@
+package jwe
+
+// content encryption (RFC 7518 section 5)
+
+import (
+	"crypto/aes"
+	gocipher "crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+
+	"github.com/priyanshujain/crypto/cipher"
+)
+
+func contentKeySize(enc ContentEncryption) (int, error) {
+	switch enc {
+	case A128GCM:
+		return 16, nil
+	case A256GCM, A128CBCHS256:
+		return 32, nil
+	default:
+		return 0, ErrUnsupportedAlgorithm
+	}
+}
+
+func encryptContent(enc ContentEncryption, cek, aad, plaintext []byte) ([]byte, []byte, []byte, error) {
+	switch enc {
+	case A128GCM, A256GCM:
+		aead, err := newGCM(cek)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		iv, err := randomBytes(aead.NonceSize())
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		sealed := aead.Seal(nil, iv, plaintext, aad)
+		split := len(sealed) - aead.Overhead()
+		return iv, sealed[:split], sealed[split:], nil
+	case A128CBCHS256:
+		iv, err := randomBytes(aes.BlockSize)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		block, err := aes.NewCipher(cek[16:])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		padded, err := cipher.AddPadding(plaintext, cipher.PKCS7)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		ciphertext := make([]byte, len(padded))
+		gocipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+		return iv, ciphertext, cbcHmacTag(cek[:16], aad, iv, ciphertext), nil
+	default:
+		return nil, nil, nil, ErrUnsupportedAlgorithm
+	}
+}
+
+func decryptContent(enc ContentEncryption, cek, iv, aad, ciphertext, tag []byte) ([]byte, error) {
+	switch enc {
+	case A128GCM, A256GCM:
+		aead, err := newGCM(cek)
+		if err != nil {
+			return nil, err
+		}
+		if len(iv) != aead.NonceSize() {
+			return nil, ErrInvalidMessage
+		}
+		sealed := append(append([]byte{}, ciphertext...), tag...)
+		return aead.Open(nil, iv, sealed, aad)
+	case A128CBCHS256:
+		if len(iv) != aes.BlockSize || len(ciphertext) == 0 || len(ciphertext)%aes.BlockSize != 0 {
+			return nil, ErrInvalidMessage
+		}
+		if subtle.ConstantTimeCompare(cbcHmacTag(cek[:16], aad, iv, ciphertext), tag) != 1 {
+			return nil, ErrDecryption
+		}
+		block, err := aes.NewCipher(cek[16:])
+		if err != nil {
+			return nil, err
+		}
+		plaintext := make([]byte, len(ciphertext))
+		gocipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+		return cipher.TrimPadding(plaintext, cipher.PKCS7)
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+}
+
+func newGCM(key []byte) (gocipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return gocipher.NewGCM(block)
+}
+
+// cbcHmacTag computes the AES_CBC_HMAC_SHA2 authentication tag (RFC 7518 section 5.2.2.1)
+func cbcHmacTag(macKey, aad, iv, ciphertext []byte) []byte {
+	h := hmac.New(sha256.New, macKey)
+	h.Write(aad)
+	h.Write(iv)
+	h.Write(ciphertext)
+	binary.Write(h, binary.BigEndian, uint64(len(aad))*8)
+	return h.Sum(nil)[:16]
+}
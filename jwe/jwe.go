@@ -0,0 +1,412 @@
+// jwe package implements JSON Web Encryption
+// https://datatracker.ietf.org/doc/html/rfc7516
+// It supports the key management algorithms RSA-OAEP, RSA-OAEP-256, A128KW, A256KW, dir and
+// ECDH-ES, the content encryption algorithms A128GCM, A256GCM and A128CBC-HS256
+// (https://datatracker.ietf.org/doc/html/rfc7518) and both the compact and the JSON serialization.
+package jwe
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type KeyAlgorithm string
+
+// key management algorithms (RFC 7518 section 4.1)
+const (
+	RsaOaep    KeyAlgorithm = "RSA-OAEP"     // RSAES OAEP using SHA-1 and MGF1 with SHA-1
+	RsaOaep256 KeyAlgorithm = "RSA-OAEP-256" // RSAES OAEP using SHA-256 and MGF1 with SHA-256
+	A128KW     KeyAlgorithm = "A128KW"       // AES Key Wrap with a 128-bit key
+	A256KW     KeyAlgorithm = "A256KW"       // AES Key Wrap with a 256-bit key
+	Direct     KeyAlgorithm = "dir"          // the shared symmetric key is the content encryption key
+	EcdhEs     KeyAlgorithm = "ECDH-ES"      // ECDH ephemeral static key agreement using Concat KDF
+)
+
+type ContentEncryption string
+
+// content encryption algorithms (RFC 7518 section 5.1)
+const (
+	A128GCM      ContentEncryption = "A128GCM"
+	A256GCM      ContentEncryption = "A256GCM"
+	A128CBCHS256 ContentEncryption = "A128CBC-HS256"
+)
+
+// errors
+var (
+	// ErrUnsupportedAlgorithm indicates an unknown alg or enc value
+	ErrUnsupportedAlgorithm = errors.New("jwe: unsupported algorithm")
+
+	// ErrInvalidKey indicates a key which does not match the algorithm
+	ErrInvalidKey = errors.New("jwe: invalid key for algorithm")
+
+	// ErrMultipleRecipients indicates dir or ECDH-ES used together with other recipients
+	ErrMultipleRecipients = errors.New("jwe: dir and ECDH-ES require a single recipient")
+
+	// ErrNotCompact indicates a message which can't use the compact serialization
+	ErrNotCompact = errors.New("jwe: message can't use the compact serialization")
+
+	// ErrInvalidMessage indicates a malformed serialization
+	ErrInvalidMessage = errors.New("jwe: invalid message")
+
+	// ErrDuplicateHeader indicates a header parameter which appears in more than one of the
+	// protected, shared unprotected and per-recipient headers
+	ErrDuplicateHeader = errors.New("jwe: duplicate header parameter")
+
+	// ErrDecryption indicates that no recipient could be decrypted with the key
+	ErrDecryption = errors.New("jwe: decryption failed")
+)
+
+// Header holds the JOSE header parameters used by this package
+type Header struct {
+	Algorithm    KeyAlgorithm      `json:"alg,omitempty"`
+	Encryption   ContentEncryption `json:"enc,omitempty"`
+	KeyID        string            `json:"kid,omitempty"`
+	EphemeralKey *Jwk              `json:"epk,omitempty"`
+	PartyUInfo   string            `json:"apu,omitempty"`
+	PartyVInfo   string            `json:"apv,omitempty"`
+	Zip          string            `json:"zip,omitempty"`
+	Critical     []string          `json:"crit,omitempty"`
+}
+
+// merge copies the parameters of other into h. The protected, shared unprotected and
+// per-recipient headers must be disjoint (RFC 7516 section 7.2.1), so a parameter set
+// in both is an error.
+func (h *Header) merge(other *Header) error {
+	if other == nil {
+		return nil
+	}
+	dup := false
+	mergeString := func(dst *string, src string) {
+		if src != "" {
+			dup = dup || *dst != ""
+			*dst = src
+		}
+	}
+	if other.Algorithm != "" {
+		dup = dup || h.Algorithm != ""
+		h.Algorithm = other.Algorithm
+	}
+	if other.Encryption != "" {
+		dup = dup || h.Encryption != ""
+		h.Encryption = other.Encryption
+	}
+	mergeString(&h.KeyID, other.KeyID)
+	if other.EphemeralKey != nil {
+		dup = dup || h.EphemeralKey != nil
+		h.EphemeralKey = other.EphemeralKey
+	}
+	mergeString(&h.PartyUInfo, other.PartyUInfo)
+	mergeString(&h.PartyVInfo, other.PartyVInfo)
+	mergeString(&h.Zip, other.Zip)
+	if other.Critical != nil {
+		dup = dup || h.Critical != nil
+		h.Critical = other.Critical
+	}
+	if dup {
+		return ErrDuplicateHeader
+	}
+	return nil
+}
+
+// checkDisjoint checks that no parameter name, known to Header or not, appears in
+// more than one of the headers
+func checkDisjoint(headers ...map[string]json.RawMessage) error {
+	seen := make(map[string]bool)
+	for _, header := range headers {
+		for name := range header {
+			if seen[name] {
+				return fmt.Errorf("%w: %s", ErrDuplicateHeader, name)
+			}
+			seen[name] = true
+		}
+	}
+	return nil
+}
+
+// Recipient describes who a message is encrypted to.
+// Key is a *keystore.PublicKey for RSA-OAEP and RSA-OAEP-256, a *keystore.EcPublicKey
+// for ECDH-ES and a []byte for A128KW, A256KW and dir.
+type Recipient struct {
+	Algorithm  KeyAlgorithm
+	Key        interface{}
+	KeyID      string
+	PartyUInfo []byte // ECDH-ES apu
+	PartyVInfo []byte // ECDH-ES apv
+}
+
+// RecipientInfo is the per-recipient part of a message
+type RecipientInfo struct {
+	Header       *Header
+	EncryptedKey []byte
+}
+
+// Message is an encrypted JWE message
+type Message struct {
+	Protected   Header
+	Unprotected *Header
+	Recipients  []RecipientInfo
+	AAD         []byte // additional authenticated data, JSON serialization only
+	IV          []byte
+	Ciphertext  []byte
+	Tag         []byte
+
+	// base64url protected header as serialized, it is part of the authenticated data
+	protected string
+}
+
+var b64 = base64.RawURLEncoding
+
+// Encrypt encrypts plaintext with a fresh content encryption key for every recipient.
+// A single recipient's parameters go to the protected header so the message can use
+// the compact serialization, with several recipients they go to the per-recipient headers.
+func Encrypt(plaintext []byte, enc ContentEncryption, recipients ...Recipient) (*Message, error) {
+	cekSize, err := contentKeySize(enc)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipients) == 0 {
+		return nil, ErrInvalidKey
+	}
+	for _, r := range recipients {
+		if (r.Algorithm == Direct || r.Algorithm == EcdhEs) && len(recipients) > 1 {
+			return nil, ErrMultipleRecipients
+		}
+	}
+
+	var cek []byte
+	if recipients[0].Algorithm != Direct && recipients[0].Algorithm != EcdhEs {
+		cek, err = randomBytes(cekSize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m := &Message{Protected: Header{Encryption: enc}}
+	for _, r := range recipients {
+		header := &Header{Algorithm: r.Algorithm, KeyID: r.KeyID}
+		encryptedKey, key, err := encryptKey(r, header, enc, cek, cekSize)
+		if err != nil {
+			return nil, err
+		}
+		if cek == nil {
+			cek = key
+		}
+		m.Recipients = append(m.Recipients, RecipientInfo{Header: header, EncryptedKey: encryptedKey})
+	}
+	if len(m.Recipients) == 1 {
+		if err := m.Protected.merge(m.Recipients[0].Header); err != nil {
+			return nil, err
+		}
+		m.Recipients[0].Header = nil
+	}
+
+	protected, err := json.Marshal(m.Protected)
+	if err != nil {
+		return nil, err
+	}
+	m.protected = b64.EncodeToString(protected)
+	m.IV, m.Ciphertext, m.Tag, err = encryptContent(enc, cek, m.aad(), plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// aad returns the additional authenticated data of the content encryption (RFC 7516 section 5.1)
+func (m *Message) aad() []byte {
+	if len(m.AAD) == 0 {
+		return []byte(m.protected)
+	}
+	return []byte(m.protected + "." + b64.EncodeToString(m.AAD))
+}
+
+// Decrypt decrypts the message with the key of one of its recipients.
+// key is a *keystore.PrivateKey, a *keystore.EcPrivateKey or a []byte.
+func (m *Message) Decrypt(key interface{}) ([]byte, error) {
+	for _, r := range m.Recipients {
+		header := &Header{}
+		for _, h := range []*Header{&m.Protected, m.Unprotected, r.Header} {
+			if err := header.merge(h); err != nil {
+				return nil, err
+			}
+		}
+		if header.Zip != "" || len(header.Critical) > 0 {
+			return nil, ErrUnsupportedAlgorithm
+		}
+		cekSize, err := contentKeySize(header.Encryption)
+		if err != nil {
+			return nil, err
+		}
+		cek, err := decryptKey(header, key, r.EncryptedKey, cekSize)
+		if err != nil {
+			// the key belongs to another recipient
+			continue
+		}
+		plaintext, err := decryptContent(header.Encryption, cek, m.IV, m.aad(), m.Ciphertext, m.Tag)
+		if err == nil {
+			return plaintext, nil
+		}
+	}
+	return nil, ErrDecryption
+}
+
+// CompactSerialize returns the compact serialization (RFC 7516 section 7.1)
+func (m *Message) CompactSerialize() (string, error) {
+	if len(m.Recipients) != 1 || m.Recipients[0].Header != nil || m.Unprotected != nil || len(m.AAD) > 0 {
+		return "", ErrNotCompact
+	}
+	return strings.Join([]string{
+		m.protected,
+		b64.EncodeToString(m.Recipients[0].EncryptedKey),
+		b64.EncodeToString(m.IV),
+		b64.EncodeToString(m.Ciphertext),
+		b64.EncodeToString(m.Tag),
+	}, "."), nil
+}
+
+type jsonRecipient struct {
+	Header       *Header `json:"header,omitempty"`
+	EncryptedKey string  `json:"encrypted_key,omitempty"`
+}
+
+type jsonMessage struct {
+	Protected   string          `json:"protected,omitempty"`
+	Unprotected *Header         `json:"unprotected,omitempty"`
+	Recipients  []jsonRecipient `json:"recipients,omitempty"`
+	AAD         string          `json:"aad,omitempty"`
+	IV          string          `json:"iv,omitempty"`
+	Ciphertext  string          `json:"ciphertext"`
+	Tag         string          `json:"tag,omitempty"`
+
+	// flattened serialization
+	Header       *Header `json:"header,omitempty"`
+	EncryptedKey string  `json:"encrypted_key,omitempty"`
+}
+
+// JSONSerialize returns the general JSON serialization (RFC 7516 section 7.2.1)
+func (m *Message) JSONSerialize() ([]byte, error) {
+	raw := jsonMessage{
+		Protected:   m.protected,
+		Unprotected: m.Unprotected,
+		AAD:         b64.EncodeToString(m.AAD),
+		IV:          b64.EncodeToString(m.IV),
+		Ciphertext:  b64.EncodeToString(m.Ciphertext),
+		Tag:         b64.EncodeToString(m.Tag),
+	}
+	for _, r := range m.Recipients {
+		raw.Recipients = append(raw.Recipients, jsonRecipient{
+			Header:       r.Header,
+			EncryptedKey: b64.EncodeToString(r.EncryptedKey),
+		})
+	}
+	return json.Marshal(raw)
+}
+
+// Parse parses a message in the compact, general JSON or flattened JSON serialization
+func Parse(data []byte) (*Message, error) {
+	s := strings.TrimSpace(string(data))
+	if strings.HasPrefix(s, "{") {
+		return parseJSON([]byte(s))
+	}
+	return parseCompact(s)
+}
+
+func parseCompact(s string) (*Message, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) != 5 {
+		return nil, ErrInvalidMessage
+	}
+	var decoded [5][]byte
+	for i, part := range parts {
+		b, err := b64.DecodeString(part)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+		}
+		decoded[i] = b
+	}
+	m := &Message{
+		protected:  parts[0],
+		Recipients: []RecipientInfo{{EncryptedKey: decoded[1]}},
+		IV:         decoded[2],
+		Ciphertext: decoded[3],
+		Tag:        decoded[4],
+	}
+	if err := json.Unmarshal(decoded[0], &m.Protected); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	return m, nil
+}
+
+func parseJSON(data []byte) (*Message, error) {
+	var raw jsonMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	if raw.Recipients == nil {
+		raw.Recipients = []jsonRecipient{{Header: raw.Header, EncryptedKey: raw.EncryptedKey}}
+	}
+
+	// the parameter names of every header, including those Header does not know
+	var names struct {
+		Unprotected map[string]json.RawMessage `json:"unprotected"`
+		Header      map[string]json.RawMessage `json:"header"`
+		Recipients  []struct {
+			Header map[string]json.RawMessage `json:"header"`
+		} `json:"recipients"`
+	}
+	if err := json.Unmarshal(data, &names); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+	}
+	var protectedNames map[string]json.RawMessage
+
+	m := &Message{protected: raw.Protected, Unprotected: raw.Unprotected}
+	if raw.Protected != "" {
+		protected, err := b64.DecodeString(raw.Protected)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+		}
+		if err := json.Unmarshal(protected, &m.Protected); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+		}
+		if err := json.Unmarshal(protected, &protectedNames); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+		}
+	}
+	if names.Recipients == nil {
+		if err := checkDisjoint(protectedNames, names.Unprotected, names.Header); err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range names.Recipients {
+		if err := checkDisjoint(protectedNames, names.Unprotected, r.Header); err != nil {
+			return nil, err
+		}
+	}
+	fields := []struct {
+		src string
+		dst *[]byte
+	}{
+		{raw.AAD, &m.AAD},
+		{raw.IV, &m.IV},
+		{raw.Ciphertext, &m.Ciphertext},
+		{raw.Tag, &m.Tag},
+	}
+	for _, f := range fields {
+		b, err := b64.DecodeString(f.src)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+		}
+		*f.dst = b
+	}
+	for _, r := range raw.Recipients {
+		encryptedKey, err := b64.DecodeString(r.EncryptedKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+		}
+		m.Recipients = append(m.Recipients, RecipientInfo{Header: r.Header, EncryptedKey: encryptedKey})
+	}
+	return m, nil
+}
@@ -0,0 +1,236 @@
+package jwe
+
+// key management (RFC 7518 section 4)
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"math/big"
+
+	"github.com/priyanshujain/crypto/cipher"
+	"github.com/priyanshujain/crypto/hash"
+	"github.com/priyanshujain/crypto/keystore"
+)
+
+// Jwk is the JSON Web Key form of an ephemeral public key (RFC 7517)
+type Jwk struct {
+	KeyType string `json:"kty"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y,omitempty"`
+}
+
+func randomBytes(size int) ([]byte, error) {
+	b := make([]byte, size)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func oaepHash(alg KeyAlgorithm) hash.HashType {
+	if alg == RsaOaep256 {
+		return hash.SHA256
+	}
+	return hash.SHA1
+}
+
+func keyWrapSize(alg KeyAlgorithm) int {
+	if alg == A128KW {
+		return 16
+	}
+	return 32
+}
+
+// encryptKey encrypts cek for a recipient and fills its header parameters.
+// For ECDH-ES the key is agreed instead and returned as the second value.
+func encryptKey(r Recipient, header *Header, enc ContentEncryption, cek []byte, cekSize int) ([]byte, []byte, error) {
+	switch r.Algorithm {
+	case RsaOaep, RsaOaep256:
+		pub, ok := r.Key.(*keystore.PublicKey)
+		if !ok {
+			return nil, nil, ErrInvalidKey
+		}
+		encryptor, err := cipher.NewRsaEncryptor(pub, cipher.WithScheme(cipher.OAEP), cipher.WithOAEPHash(oaepHash(r.Algorithm)))
+		if err != nil {
+			return nil, nil, err
+		}
+		encryptedKey, err := encryptor.Encrypt(cek)
+		return encryptedKey, nil, err
+	case A128KW, A256KW:
+		kek, ok := r.Key.([]byte)
+		if !ok || len(kek) != keyWrapSize(r.Algorithm) {
+			return nil, nil, ErrInvalidKey
+		}
+		encryptedKey, err := cipher.WrapKey(kek, cek)
+		return encryptedKey, nil, err
+	case Direct:
+		key, ok := r.Key.([]byte)
+		if !ok || len(key) != cekSize {
+			return nil, nil, ErrInvalidKey
+		}
+		return nil, key, nil
+	case EcdhEs:
+		pub, ok := r.Key.(*keystore.EcPublicKey)
+		if !ok {
+			return nil, nil, ErrInvalidKey
+		}
+		ephemeral, err := pub.Key.Curve().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, nil, err
+		}
+		header.EphemeralKey, err = newJwk(ephemeral.PublicKey())
+		if err != nil {
+			return nil, nil, err
+		}
+		header.PartyUInfo = b64.EncodeToString(r.PartyUInfo)
+		header.PartyVInfo = b64.EncodeToString(r.PartyVInfo)
+		z, err := ephemeral.ECDH(pub.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, concatKDF(z, string(enc), r.PartyUInfo, r.PartyVInfo, cekSize), nil
+	default:
+		return nil, nil, ErrUnsupportedAlgorithm
+	}
+}
+
+// decryptKey recovers the content encryption key of a recipient
+func decryptKey(header *Header, key interface{}, encryptedKey []byte, cekSize int) ([]byte, error) {
+	switch header.Algorithm {
+	case RsaOaep, RsaOaep256:
+		priv, ok := key.(*keystore.PrivateKey)
+		if !ok {
+			return nil, ErrInvalidKey
+		}
+		decryptor, err := cipher.NewRsaDecryptor(priv, cipher.WithScheme(cipher.OAEP), cipher.WithOAEPHash(oaepHash(header.Algorithm)))
+		if err != nil {
+			return nil, err
+		}
+		// a wrong key yields a random cek and fails at the content decryption
+		return decryptor.DecryptSessionKey(encryptedKey, cekSize)
+	case A128KW, A256KW:
+		kek, ok := key.([]byte)
+		if !ok || len(kek) != keyWrapSize(header.Algorithm) {
+			return nil, ErrInvalidKey
+		}
+		cek, err := cipher.UnwrapKey(kek, encryptedKey)
+		if err != nil {
+			return nil, err
+		}
+		if len(cek) != cekSize {
+			return nil, ErrInvalidKey
+		}
+		return cek, nil
+	case Direct:
+		cek, ok := key.([]byte)
+		if !ok || len(cek) != cekSize || len(encryptedKey) != 0 {
+			return nil, ErrInvalidKey
+		}
+		return cek, nil
+	case EcdhEs:
+		priv, ok := key.(*keystore.EcPrivateKey)
+		if !ok || header.EphemeralKey == nil || len(encryptedKey) != 0 {
+			return nil, ErrInvalidKey
+		}
+		ephemeral, err := header.EphemeralKey.publicKey()
+		if err != nil {
+			return nil, err
+		}
+		if ephemeral.Curve() != priv.Key.Curve() {
+			return nil, ErrInvalidKey
+		}
+		z, err := priv.Key.ECDH(ephemeral)
+		if err != nil {
+			return nil, err
+		}
+		apu, err := b64.DecodeString(header.PartyUInfo)
+		if err != nil {
+			return nil, ErrInvalidMessage
+		}
+		apv, err := b64.DecodeString(header.PartyVInfo)
+		if err != nil {
+			return nil, ErrInvalidMessage
+		}
+		return concatKDF(z, string(header.Encryption), apu, apv, cekSize), nil
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+}
+
+// concatKDF implements the Concat KDF of NIST SP 800-56A with SHA-256 as profiled
+// by RFC 7518 section 4.6.2
+func concatKDF(z []byte, algorithmID string, apu, apv []byte, size int) []byte {
+	otherInfo := lengthPrefixed([]byte(algorithmID))
+	otherInfo = append(otherInfo, lengthPrefixed(apu)...)
+	otherInfo = append(otherInfo, lengthPrefixed(apv)...)
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(size*8))
+
+	var key []byte
+	for counter := uint32(1); len(key) < size; counter++ {
+		h := sha256.New()
+		binary.Write(h, binary.BigEndian, counter)
+		h.Write(z)
+		h.Write(otherInfo)
+		key = h.Sum(key)
+	}
+	return key[:size]
+}
+
+func lengthPrefixed(b []byte) []byte {
+	return append(binary.BigEndian.AppendUint32(nil, uint32(len(b))), b...)
+}
+
+func newJwk(pub *ecdh.PublicKey) (*Jwk, error) {
+	raw := pub.Bytes()
+	switch pub.Curve() {
+	case ecdh.P256(), ecdh.P384():
+		crv := "P-256"
+		if pub.Curve() == ecdh.P384() {
+			crv = "P-384"
+		}
+		// uncompressed point 0x04 || x || y
+		size := (len(raw) - 1) / 2
+		return &Jwk{
+			KeyType: "EC",
+			Curve:   crv,
+			X:       b64.EncodeToString(raw[1 : 1+size]),
+			Y:       b64.EncodeToString(raw[1+size:]),
+		}, nil
+	case ecdh.X25519():
+		// RFC 8037
+		return &Jwk{KeyType: "OKP", Curve: "X25519", X: b64.EncodeToString(raw)}, nil
+	default:
+		return nil, keystore.ErrUnsupportedCurve
+	}
+}
+
+func (k *Jwk) publicKey() (*ecdh.PublicKey, error) {
+	x, err := b64.DecodeString(k.X)
+	if err != nil {
+		return nil, ErrInvalidMessage
+	}
+	switch {
+	case k.KeyType == "OKP" && k.Curve == "X25519":
+		return ecdh.X25519().NewPublicKey(x)
+	case k.KeyType == "EC" && (k.Curve == "P-256" || k.Curve == "P-384"):
+		curve, size := ecdh.P256(), 32
+		if k.Curve == "P-384" {
+			curve, size = ecdh.P384(), 48
+		}
+		y, err := b64.DecodeString(k.Y)
+		if err != nil || len(x) > size || len(y) > size {
+			return nil, ErrInvalidMessage
+		}
+		raw := make([]byte, 1+2*size)
+		raw[0] = 4
+		new(big.Int).SetBytes(x).FillBytes(raw[1 : 1+size])
+		new(big.Int).SetBytes(y).FillBytes(raw[1+size:])
+		return curve.NewPublicKey(raw)
+	default:
+		return nil, keystore.ErrUnsupportedCurve
+	}
+}
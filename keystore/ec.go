@@ -0,0 +1,183 @@
+// ec imports or creates elliptic curve keys for key agreement (ECDH)
+// it by default uses pem format for all keys
+package keystore
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+type EcCurve uint
+
+// supported curves
+const (
+	P256   EcCurve = 1 + iota // NIST P-256 (secp256r1)
+	P384                      // NIST P-384 (secp384r1)
+	X25519                    // https://datatracker.ietf.org/doc/html/rfc7748
+)
+
+// errors
+var (
+	// ErrUnsupportedCurve indicates a curve other than P-256, P-384 and X25519
+	ErrUnsupportedCurve = errors.New("unsupported elliptic curve")
+)
+
+type EcPrivateKey struct {
+	Key *ecdh.PrivateKey
+}
+
+type EcPublicKey struct {
+	Key *ecdh.PublicKey
+}
+
+// EcdhCurve returns the crypto/ecdh implementation of a curve
+func EcdhCurve(curve EcCurve) (ecdh.Curve, error) {
+	switch curve {
+	case P256:
+		return ecdh.P256(), nil
+	case P384:
+		return ecdh.P384(), nil
+	case X25519:
+		return ecdh.X25519(), nil
+	default:
+		return nil, ErrUnsupportedCurve
+	}
+}
+
+func curveOf(c ecdh.Curve) EcCurve {
+	switch c {
+	case ecdh.P256():
+		return P256
+	case ecdh.P384():
+		return P384
+	case ecdh.X25519():
+		return X25519
+	default:
+		return 0
+	}
+}
+
+// PublicKey returns the public key from a private key in *EcPublicKey format
+func (k *EcPrivateKey) PublicKey() *EcPublicKey {
+	return &EcPublicKey{Key: k.Key.PublicKey()}
+}
+
+// Curve returns the curve of the key
+func (k *EcPrivateKey) Curve() EcCurve {
+	return curveOf(k.Key.Curve())
+}
+
+// Curve returns the curve of the key
+func (k *EcPublicKey) Curve() EcCurve {
+	return curveOf(k.Key.Curve())
+}
+
+// generate an elliptic curve key pair on a given curve
+func GenerateEcKeyPair(curve EcCurve) (*EcPrivateKey, *EcPublicKey, error) {
+	c, err := EcdhCurve(curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := c.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &EcPrivateKey{Key: key}, &EcPublicKey{Key: key.PublicKey()}, nil
+}
+
+// ParseEcPrivateKeyFromPem parses a private key from pem format,
+// both SEC 1 ("EC PRIVATE KEY") and PKCS #8 ("PRIVATE KEY") blocks are accepted
+func ParseEcPrivateKeyFromPem(pemBytes []byte) (*EcPrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("failed to parse private key")
+	}
+	var key interface{}
+	var err error
+	if block.Type == "EC PRIVATE KEY" {
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ecdhKey *ecdh.PrivateKey
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		ecdhKey, err = k.ECDH()
+		if err != nil {
+			return nil, err
+		}
+	case *ecdh.PrivateKey:
+		ecdhKey = k
+	default:
+		return nil, errors.New("failed to parse private key")
+	}
+	if curveOf(ecdhKey.Curve()) == 0 {
+		return nil, ErrUnsupportedCurve
+	}
+	return &EcPrivateKey{Key: ecdhKey}, nil
+}
+
+// ParseEcPublicKeyFromPem parses a public key from pem format
+func ParseEcPublicKeyFromPem(pemBytes []byte) (*EcPublicKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("failed to parse public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	var ecdhKey *ecdh.PublicKey
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		ecdhKey, err = k.ECDH()
+		if err != nil {
+			return nil, err
+		}
+	case *ecdh.PublicKey:
+		ecdhKey = k
+	default:
+		return nil, errors.New("failed to parse public key")
+	}
+	if curveOf(ecdhKey.Curve()) == 0 {
+		return nil, ErrUnsupportedCurve
+	}
+	return &EcPublicKey{Key: ecdhKey}, nil
+}
+
+// ConvertEcPrivateKeyToPem creates a PKCS #8 private key in pem format
+func ConvertEcPrivateKeyToPem(k *EcPrivateKey) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(k.Key)
+	if err != nil {
+		return nil, err
+	}
+	privKey := pem.EncodeToMemory(&pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: der,
+	})
+	// strip last newline
+	privKey = privKey[:len(privKey)-1]
+	return privKey, nil
+}
+
+// ConvertEcPublicKeyToPem creates a PKIX public key in pem format
+func ConvertEcPublicKeyToPem(k *EcPublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(k.Key)
+	if err != nil {
+		return nil, err
+	}
+	pubKey := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: der,
+	})
+	// strip last newline
+	pubKey = pubKey[:len(pubKey)-1]
+	return pubKey, nil
+}
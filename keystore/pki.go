@@ -3,11 +3,14 @@
 package keystore
 
 import (
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
+	"io"
 )
 
 type PrivateKey struct {
@@ -23,6 +26,38 @@ func (k *PrivateKey) PublicKey() *PublicKey {
 	return &PublicKey{Key: k.Key.PublicKey}
 }
 
+var (
+	_ crypto.Signer    = (*PrivateKey)(nil)
+	_ crypto.Decrypter = (*PrivateKey)(nil)
+)
+
+// Public returns the *rsa.PublicKey, so PrivateKey works as crypto.Signer and crypto.Decrypter
+// with x509.CreateCertificate and tls.Certificate
+func (k *PrivateKey) Public() crypto.PublicKey {
+	return &k.Key.PublicKey
+}
+
+// Sign signs a digest with PKCS1 v1.5, or with PSS when opts is *rsa.PSSOptions (crypto.Signer)
+func (k *PrivateKey) Sign(random io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return k.Key.Sign(random, digest, opts)
+}
+
+// Decrypt decrypts with PKCS1 v1.5 or with OAEP when opts is *rsa.OAEPOptions (crypto.Decrypter)
+func (k *PrivateKey) Decrypt(random io.Reader, ciphertext []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	return k.Key.Decrypt(random, ciphertext, opts)
+}
+
+// Fingerprint returns the SHA-256 hash of the DER encoded PKIX public key,
+// the same as openssl rsa -pubout -outform DER | sha256sum
+func (k *PublicKey) Fingerprint() ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(&k.Key)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(der)
+	return sum[:], nil
+}
+
 // generate a key pair given bits size
 // It uses multi prime RSA key generation algorithm with number of primes as 2
 // GenerateMultiPrimeKey(random, 2, bits)
@@ -2,8 +2,19 @@ package keystore
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
 	"fmt"
+	"math/big"
+	"net"
 	"testing"
+	"time"
 )
 
 var keysTestCases = []struct {
@@ -101,6 +112,22 @@ func TestKeys(t *testing.T) {
 	}
 }
 
+func TestFingerprint(t *testing.T) {
+	publicKey, err := ParsePublicKeyFromPem([]byte(keysTestCases[0].publicKeyPem))
+	if err != nil {
+		t.Fatalf("ParsePublicKeyFromPem encountered error: %s", err)
+	}
+	fingerprint, err := publicKey.Fingerprint()
+	if err != nil {
+		t.Fatalf("Fingerprint() error = %v", err)
+	}
+	// openssl rsa -pubout -outform DER | sha256sum
+	want := "2f30ad72ccd3cc0f49f167b9f9a6ea2cb6c9818f6cb488babe3f771761814c77"
+	if hex.EncodeToString(fingerprint) != want {
+		t.Errorf("Fingerprint() = %x, want %s", fingerprint, want)
+	}
+}
+
 func TestJunkKeys(t *testing.T) {
 	for _, test := range keysTestCases {
 		if !test.wantErr {
@@ -122,3 +149,81 @@ func TestJunkKeys(t *testing.T) {
 		})
 	}
 }
+
+// selfSigned creates a certificate for localhost signed by the private key
+func selfSigned(t *testing.T, priv *PrivateKey, algorithm x509.SignatureAlgorithm) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		SignatureAlgorithm:    algorithm,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, priv.Public(), priv)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate() error = %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate() error = %v", err)
+	}
+	return cert
+}
+
+func TestPrivateKeyCryptoSigner(t *testing.T) {
+	priv, err := ParsePrivateKeyFromPem([]byte(keysTestCases[0].privateKeyPem))
+	if err != nil {
+		t.Fatalf("ParsePrivateKeyFromPem encountered error: %s", err)
+	}
+	for _, algorithm := range []x509.SignatureAlgorithm{x509.SHA256WithRSA, x509.SHA384WithRSAPSS} {
+		cert := selfSigned(t, priv, algorithm)
+		if err := cert.CheckSignatureFrom(cert); err != nil {
+			t.Errorf("%v: CheckSignatureFrom() error = %v", algorithm, err)
+		}
+	}
+
+	// TLS 1.3 handshake, which signs with PSS
+	cert := selfSigned(t, priv, x509.SHA256WithRSA)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	serverConn, clientConn := net.Pipe()
+	server := tls.Server(serverConn, &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{cert.Raw}, PrivateKey: priv}},
+	})
+	client := tls.Client(clientConn, &tls.Config{RootCAs: pool, ServerName: "localhost"})
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Handshake()
+	}()
+	if err := client.Handshake(); err != nil {
+		t.Errorf("client Handshake() error = %v", err)
+	}
+	if err := <-errs; err != nil {
+		t.Errorf("server Handshake() error = %v", err)
+	}
+	clientConn.Close()
+	serverConn.Close()
+}
+
+func TestPrivateKeyCryptoDecrypter(t *testing.T) {
+	priv, _ := ParsePrivateKeyFromPem([]byte(keysTestCases[0].privateKeyPem))
+	msg := []byte("session key")
+
+	ciphertext, _ := rsa.EncryptOAEP(sha256.New(), rand.Reader, &priv.Key.PublicKey, msg, nil)
+	plaintext, err := priv.Decrypt(rand.Reader, ciphertext, &rsa.OAEPOptions{Hash: crypto.SHA256})
+	if err != nil || !bytes.Equal(plaintext, msg) {
+		t.Errorf("Decrypt() with OAEP = %q, %v", plaintext, err)
+	}
+
+	//lint:ignore SA1019 testing the PKCS1 v1.5 decryption
+	ciphertext, _ = rsa.EncryptPKCS1v15(rand.Reader, &priv.Key.PublicKey, msg)
+	plaintext, err = priv.Decrypt(rand.Reader, ciphertext, nil)
+	if err != nil || !bytes.Equal(plaintext, msg) {
+		t.Errorf("Decrypt() with PKCS1 v1.5 = %q, %v", plaintext, err)
+	}
+}
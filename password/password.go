@@ -0,0 +1,405 @@
+// password package hashes passwords for storage with a random salt per hash and tunable costs.
+// Argon2id, scrypt and PBKDF2 hashes use the PHC string format like
+// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>, bcrypt hashes use the usual $2a$<cost>$ format.
+package password
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/priyanshujain/crypto/hash"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+type Algorithm uint
+
+// password hashing algorithms
+const (
+	Argon2id Algorithm = 1 + iota // https://datatracker.ietf.org/doc/html/rfc9106
+	Bcrypt                        // https://en.wikipedia.org/wiki/Bcrypt
+	Scrypt                        // https://datatracker.ietf.org/doc/html/rfc7914
+	PBKDF2                        // https://datatracker.ietf.org/doc/html/rfc8018#section-5.2
+)
+
+// defaults follow the OWASP password storage cheat sheet
+// https://cheatsheetseries.owasp.org/cheatsheets/Password_Storage_Cheat_Sheet.html
+const (
+	defaultArgon2Time    = 2
+	defaultArgon2Memory  = 19 * 1024 // KiB
+	defaultArgon2Threads = 1
+
+	defaultBcryptCost = 12
+
+	defaultScryptLogN = 17
+	defaultScryptR    = 8
+	defaultScryptP    = 1
+
+	defaultPbkdf2Iterations = 600000
+
+	defaultSaltSize = 16
+	defaultKeySize  = 32
+
+	minSaltSize = 8
+	minKeySize  = 16
+)
+
+// upper limits, so a corrupted or planted hash can't make Verify exhaust memory or CPU
+const (
+	maxArgon2Time   = 16
+	maxArgon2Memory = 1 << 20 // KiB, 1 GiB
+
+	maxBcryptCost = 16
+
+	maxScryptLogN        = 24
+	maxScryptMemory      = 1 << 30 // bytes, 128 * r * N
+	maxScryptParallelism = 16
+
+	maxPbkdf2Iterations = 10000000
+
+	maxSaltSize = 1024
+	maxKeySize  = 1024
+)
+
+// errors
+var (
+	// ErrMismatchedPassword indicates a password which does not match the hash
+	ErrMismatchedPassword = errors.New("password does not match the hash")
+
+	// ErrInvalidHash indicates a malformed password hash string
+	ErrInvalidHash = errors.New("invalid password hash")
+
+	// ErrUnsupportedAlgorithm indicates an unknown algorithm or a hash string of an unknown algorithm
+	ErrUnsupportedAlgorithm = errors.New("unsupported password hashing algorithm")
+
+	// ErrInvalidParams indicates cost parameters or sizes outside of the allowed ranges
+	ErrInvalidParams = errors.New("invalid password hashing parameters")
+)
+
+// Hasher hashes passwords with one algorithm and set of parameters
+type Hasher struct {
+	algorithm Algorithm
+
+	// Argon2id
+	time    uint32
+	memory  uint32 // KiB
+	threads uint8
+
+	// bcrypt
+	cost int
+
+	// scrypt, N = 2^logN
+	logN uint8
+	r    int
+	p    int
+
+	// PBKDF2
+	hash       hash.HashType
+	iterations int
+
+	saltSize int
+	keySize  int
+}
+
+// Option configures a Hasher created by NewHasher
+type Option func(*Hasher)
+
+// WithArgon2idParams sets the passes, the memory in KiB and the parallelism of Argon2id,
+// up to 16 passes and 1 GiB
+func WithArgon2idParams(time, memory uint32, threads uint8) Option {
+	return func(x *Hasher) {
+		x.time, x.memory, x.threads = time, memory, threads
+	}
+}
+
+// WithBcryptCost sets the bcrypt cost, the log2 of the number of rounds, up to 16
+func WithBcryptCost(cost int) Option {
+	return func(x *Hasher) {
+		x.cost = cost
+	}
+}
+
+// WithScryptParams sets the scrypt cost N = 2^logN, the block size r and the parallelism p,
+// up to logN 24, 1 GiB of memory (128 * r * N bytes) and p 16
+func WithScryptParams(logN uint8, r, p int) Option {
+	return func(x *Hasher) {
+		x.logN, x.r, x.p = logN, r, p
+	}
+}
+
+// WithPbkdf2Params sets the HMAC hash and the iterations of PBKDF2, up to 10 million,
+// the default hash is SHA256
+func WithPbkdf2Params(htype hash.HashType, iterations int) Option {
+	return func(x *Hasher) {
+		x.hash, x.iterations = htype, iterations
+	}
+}
+
+// WithSaltSize sets the salt size in bytes, bcrypt always uses 16
+func WithSaltSize(size int) Option {
+	return func(x *Hasher) {
+		x.saltSize = size
+	}
+}
+
+// WithKeySize sets the size of the derived hash in bytes, bcrypt always uses 23
+func WithKeySize(size int) Option {
+	return func(x *Hasher) {
+		x.keySize = size
+	}
+}
+
+// NewHasher returns a Hasher for an algorithm with the default parameters
+// unless they are changed with options
+func NewHasher(algorithm Algorithm, opts ...Option) (*Hasher, error) {
+	x := &Hasher{
+		algorithm:  algorithm,
+		time:       defaultArgon2Time,
+		memory:     defaultArgon2Memory,
+		threads:    defaultArgon2Threads,
+		cost:       defaultBcryptCost,
+		logN:       defaultScryptLogN,
+		r:          defaultScryptR,
+		p:          defaultScryptP,
+		hash:       hash.SHA256,
+		iterations: defaultPbkdf2Iterations,
+		saltSize:   defaultSaltSize,
+		keySize:    defaultKeySize,
+	}
+	for _, opt := range opts {
+		opt(x)
+	}
+	if err := x.validate(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+func (x *Hasher) validate() error {
+	if x.saltSize < minSaltSize || x.saltSize > maxSaltSize || x.keySize < minKeySize || x.keySize > maxKeySize {
+		return ErrInvalidParams
+	}
+	switch x.algorithm {
+	case Argon2id:
+		// RFC 9106 section 3.1
+		if x.time < 1 || x.threads < 1 || x.memory < 8*uint32(x.threads) {
+			return ErrInvalidParams
+		}
+		if x.time > maxArgon2Time || x.memory > maxArgon2Memory {
+			return ErrInvalidParams
+		}
+	case Bcrypt:
+		if x.cost < bcrypt.MinCost || x.cost > maxBcryptCost {
+			return ErrInvalidParams
+		}
+	case Scrypt:
+		// RFC 7914 section 2
+		if x.logN < 1 || x.r < 1 || x.p < 1 || uint64(x.r)*uint64(x.p) >= 1<<30 {
+			return ErrInvalidParams
+		}
+		if x.logN > maxScryptLogN || x.p > maxScryptParallelism || 128*uint64(x.r)<<x.logN > maxScryptMemory {
+			return ErrInvalidParams
+		}
+	case PBKDF2:
+		if x.iterations < 1 || x.iterations > maxPbkdf2Iterations {
+			return ErrInvalidParams
+		}
+		if _, err := pbkdf2Id(x.hash); err != nil {
+			return err
+		}
+	default:
+		return ErrUnsupportedAlgorithm
+	}
+	return nil
+}
+
+// Hash hashes a password with a new random salt
+func (x *Hasher) Hash(password []byte) (string, error) {
+	if x.algorithm == Bcrypt {
+		encoded, err := bcrypt.GenerateFromPassword(password, x.cost)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	}
+	salt := make([]byte, x.saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return "", err
+	}
+	return x.hashWithSalt(password, salt)
+}
+
+func (x *Hasher) hashWithSalt(password, salt []byte) (string, error) {
+	key, err := x.deriveKey(password, salt, x.keySize)
+	if err != nil {
+		return "", err
+	}
+	p := &phc{salt: salt, hash: key}
+	switch x.algorithm {
+	case Argon2id:
+		p.id, p.version = "argon2id", argon2.Version
+		p.params = []phcParam{{"m", int(x.memory)}, {"t", int(x.time)}, {"p", int(x.threads)}}
+	case Scrypt:
+		p.id = "scrypt"
+		p.params = []phcParam{{"ln", int(x.logN)}, {"r", x.r}, {"p", x.p}}
+	case PBKDF2:
+		p.id, _ = pbkdf2Id(x.hash)
+		p.params = []phcParam{{"i", x.iterations}}
+	}
+	return p.String(), nil
+}
+
+func (x *Hasher) deriveKey(password, salt []byte, size int) ([]byte, error) {
+	switch x.algorithm {
+	case Argon2id:
+		return argon2.IDKey(password, salt, x.time, x.memory, x.threads, uint32(size)), nil
+	case Scrypt:
+		return scrypt.Key(password, salt, 1<<x.logN, x.r, x.p, size)
+	case PBKDF2:
+		newFunc, err := hash.NewFunc(x.hash)
+		if err != nil {
+			return nil, err
+		}
+		return pbkdf2.Key(newFunc, string(password), salt, x.iterations, size)
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+}
+
+// NeedsRehash reports whether an encoded hash uses another algorithm or other parameters
+// than the Hasher, so the password should be hashed again after a successful Verify
+func (x *Hasher) NeedsRehash(encoded string) bool {
+	if x.algorithm == Bcrypt {
+		if !isBcrypt(encoded) {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return err != nil || cost != x.cost
+	}
+	stored, p, err := parse(encoded)
+	if err != nil || stored.algorithm != x.algorithm {
+		return true
+	}
+	if len(p.salt) < x.saltSize || len(p.hash) != x.keySize {
+		return true
+	}
+	switch x.algorithm {
+	case Argon2id:
+		return stored.time != x.time || stored.memory != x.memory || stored.threads != x.threads
+	case Scrypt:
+		return stored.logN != x.logN || stored.r != x.r || stored.p != x.p
+	default:
+		return stored.hash != x.hash || stored.iterations != x.iterations
+	}
+}
+
+// Verify checks a password against an encoded hash of any of the algorithms,
+// the algorithm and parameters are taken from the hash
+func Verify(password []byte, encoded string) error {
+	if isBcrypt(encoded) {
+		cost, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return ErrInvalidHash
+		}
+		if cost > maxBcryptCost {
+			return ErrInvalidParams
+		}
+		err = bcrypt.CompareHashAndPassword([]byte(encoded), password)
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatchedPassword
+		}
+		return err
+	}
+	x, p, err := parse(encoded)
+	if err != nil {
+		return err
+	}
+	key, err := x.deriveKey(password, p.salt, len(p.hash))
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(key, p.hash) != 1 {
+		return ErrMismatchedPassword
+	}
+	return nil
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+// parse returns a Hasher with the algorithm and parameters of a PHC string
+func parse(encoded string) (*Hasher, *phc, error) {
+	p, err := parsePhc(encoded)
+	if err != nil {
+		return nil, nil, err
+	}
+	x := &Hasher{saltSize: len(p.salt), keySize: len(p.hash)}
+	switch {
+	case p.id == "argon2id":
+		if p.version != argon2.Version || len(p.params) != 3 {
+			return nil, nil, ErrInvalidHash
+		}
+		m, err1 := p.param("m")
+		t, err2 := p.param("t")
+		threads, err3 := p.param("p")
+		if err := errors.Join(err1, err2, err3); err != nil {
+			return nil, nil, ErrInvalidHash
+		}
+		if m > math.MaxUint32 || t > math.MaxUint32 || threads > math.MaxUint8 {
+			return nil, nil, ErrInvalidParams
+		}
+		x.algorithm, x.memory, x.time, x.threads = Argon2id, uint32(m), uint32(t), uint8(threads)
+	case p.id == "scrypt":
+		if p.version != 0 || len(p.params) != 3 {
+			return nil, nil, ErrInvalidHash
+		}
+		logN, err1 := p.param("ln")
+		r, err2 := p.param("r")
+		parallelism, err3 := p.param("p")
+		if err := errors.Join(err1, err2, err3); err != nil {
+			return nil, nil, ErrInvalidHash
+		}
+		if logN > math.MaxUint8 {
+			return nil, nil, ErrInvalidParams
+		}
+		x.algorithm, x.logN, x.r, x.p = Scrypt, uint8(logN), r, parallelism
+	case strings.HasPrefix(p.id, "pbkdf2-"):
+		htype, err := hash.ParseHashType(strings.TrimPrefix(p.id, "pbkdf2-"))
+		if err != nil {
+			return nil, nil, ErrUnsupportedAlgorithm
+		}
+		if p.version != 0 || len(p.params) != 1 {
+			return nil, nil, ErrInvalidHash
+		}
+		iterations, err := p.param("i")
+		if err != nil {
+			return nil, nil, err
+		}
+		x.algorithm, x.hash, x.iterations = PBKDF2, htype, iterations
+	default:
+		return nil, nil, ErrUnsupportedAlgorithm
+	}
+	if err := x.validate(); err != nil {
+		return nil, nil, err
+	}
+	return x, p, nil
+}
+
+// pbkdf2Id returns the PHC identifier like pbkdf2-sha256 or pbkdf2-sha512-256
+func pbkdf2Id(htype hash.HashType) (string, error) {
+	// extendable-output functions have no HMAC
+	if htype == hash.SHAKE128 || htype == hash.SHAKE256 {
+		return "", ErrInvalidParams
+	}
+	if _, err := hash.NewFunc(htype); err != nil {
+		return "", ErrInvalidParams
+	}
+	return "pbkdf2-" + strings.ReplaceAll(htype.String(), "/", "-"), nil
+}
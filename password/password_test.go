@@ -0,0 +1,235 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/priyanshujain/crypto/hash"
+)
+
+// cheap parameters to keep the tests fast
+var testHashers = []struct {
+	name      string
+	algorithm Algorithm
+	opts      []Option
+	prefix    string
+}{
+	{
+		name:      "Argon2id",
+		algorithm: Argon2id,
+		opts:      []Option{WithArgon2idParams(1, 64, 2)},
+		prefix:    "$argon2id$v=19$m=64,t=1,p=2$",
+	},
+	{
+		name:      "Bcrypt",
+		algorithm: Bcrypt,
+		opts:      []Option{WithBcryptCost(4)},
+		prefix:    "$2a$04$",
+	},
+	{
+		name:      "Scrypt",
+		algorithm: Scrypt,
+		opts:      []Option{WithScryptParams(4, 8, 1)},
+		prefix:    "$scrypt$ln=4,r=8,p=1$",
+	},
+	{
+		name:      "PBKDF2",
+		algorithm: PBKDF2,
+		opts:      []Option{WithPbkdf2Params(hash.SHA512, 10)},
+		prefix:    "$pbkdf2-sha512$i=10$",
+	},
+	{
+		name:      "PBKDF2 SHA512/256",
+		algorithm: PBKDF2,
+		opts:      []Option{WithPbkdf2Params(hash.SHA512_256, 10), WithSaltSize(32), WithKeySize(64)},
+		prefix:    "$pbkdf2-sha512-256$i=10$",
+	},
+}
+
+func TestHashVerify(t *testing.T) {
+	for _, tt := range testHashers {
+		x, err := NewHasher(tt.algorithm, tt.opts...)
+		if err != nil {
+			t.Fatalf("%s: NewHasher() error = %v", tt.name, err)
+		}
+		encoded, err := x.Hash([]byte("correct horse"))
+		if err != nil {
+			t.Fatalf("%s: Hash() error = %v", tt.name, err)
+		}
+		if !strings.HasPrefix(encoded, tt.prefix) {
+			t.Errorf("%s: Hash() = %s, want prefix %s", tt.name, encoded, tt.prefix)
+		}
+		if err := Verify([]byte("correct horse"), encoded); err != nil {
+			t.Errorf("%s: Verify() error = %v", tt.name, err)
+		}
+		if err := Verify([]byte("battery staple"), encoded); err != ErrMismatchedPassword {
+			t.Errorf("%s: Verify() of a wrong password error = %v, want %v", tt.name, err, ErrMismatchedPassword)
+		}
+		if x.NeedsRehash(encoded) {
+			t.Errorf("%s: NeedsRehash() of its own hash = true", tt.name)
+		}
+		again, _ := x.Hash([]byte("correct horse"))
+		if again == encoded {
+			t.Errorf("%s: Hash() reused the salt", tt.name)
+		}
+	}
+}
+
+// hashes of other implementations
+func TestVerifyVectors(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		encoded  string
+	}{
+		{
+			// Python hashlib.scrypt
+			name:     "Scrypt",
+			password: "correct horse",
+			encoded:  "$scrypt$ln=10,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$A9lBa6RTbfBovWqamVIqXKovIl4Vk6OZyVojLJmYmSI",
+		},
+		{
+			// Python hashlib.pbkdf2_hmac
+			name:     "PBKDF2-SHA256",
+			password: "correct horse",
+			encoded:  "$pbkdf2-sha256$i=1000$c2FsdHNhbHRzYWx0c2FsdA$BBs+1+PaslLtBPULUr8/lQicvVuHiEPMz0i8MjLCbzM",
+		},
+		{
+			name:     "PBKDF2-SHA512",
+			password: "correct horse",
+			encoded:  "$pbkdf2-sha512$i=1000$c2FsdHNhbHRzYWx0c2FsdA$EHLBej+uEvxlmr0QnHnceg1vM6h1wbwSP5XErh3SmizNDWZsIi28RPGARe0EssjGRoiACzxgHjNvqwJY1zxKig",
+		},
+		{
+			// Openwall crypt_blowfish test vectors
+			name:     "Bcrypt",
+			password: "U*U",
+			encoded:  "$2a$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW",
+		},
+		{
+			name:     "Bcrypt 2y",
+			password: "U*U",
+			encoded:  "$2y$05$CCCCCCCCCCCCCCCCCCCCC.E5YPO9kmyuRGyh0XouQYb4YMJKvyOeW",
+		},
+	}
+	for _, tt := range tests {
+		if err := Verify([]byte(tt.password), tt.encoded); err != nil {
+			t.Errorf("%s: Verify() error = %v", tt.name, err)
+		}
+		if err := Verify([]byte(tt.password+"!"), tt.encoded); err != ErrMismatchedPassword {
+			t.Errorf("%s: Verify() of a wrong password error = %v, want %v", tt.name, err, ErrMismatchedPassword)
+		}
+	}
+}
+
+func TestArgon2idDeterministic(t *testing.T) {
+	x, _ := NewHasher(Argon2id, WithArgon2idParams(1, 64, 1))
+	salt := []byte("saltsaltsaltsalt")
+	a, err := x.hashWithSalt([]byte("correct horse"), salt)
+	if err != nil {
+		t.Fatalf("hashWithSalt() error = %v", err)
+	}
+	b, _ := x.hashWithSalt([]byte("correct horse"), salt)
+	if a != b || !strings.HasPrefix(a, "$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$") {
+		t.Errorf("hashWithSalt() = %s, %s", a, b)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	old, _ := NewHasher(Scrypt, WithScryptParams(4, 8, 1))
+	encoded, _ := old.Hash([]byte("correct horse"))
+
+	tests := []struct {
+		name      string
+		algorithm Algorithm
+		opts      []Option
+		want      bool
+	}{
+		{"same", Scrypt, []Option{WithScryptParams(4, 8, 1)}, false},
+		{"higher cost", Scrypt, []Option{WithScryptParams(5, 8, 1)}, true},
+		{"other block size", Scrypt, []Option{WithScryptParams(4, 16, 1)}, true},
+		{"longer key", Scrypt, []Option{WithScryptParams(4, 8, 1), WithKeySize(64)}, true},
+		{"longer salt", Scrypt, []Option{WithScryptParams(4, 8, 1), WithSaltSize(32)}, true},
+		{"other algorithm", Argon2id, []Option{WithArgon2idParams(1, 64, 1)}, true},
+		{"bcrypt", Bcrypt, []Option{WithBcryptCost(4)}, true},
+	}
+	for _, tt := range tests {
+		x, err := NewHasher(tt.algorithm, tt.opts...)
+		if err != nil {
+			t.Fatalf("%s: NewHasher() error = %v", tt.name, err)
+		}
+		if got := x.NeedsRehash(encoded); got != tt.want {
+			t.Errorf("%s: NeedsRehash() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	bcrypt4, _ := NewHasher(Bcrypt, WithBcryptCost(4))
+	bcrypt5, _ := NewHasher(Bcrypt, WithBcryptCost(5))
+	encoded, _ = bcrypt4.Hash([]byte("correct horse"))
+	if bcrypt4.NeedsRehash(encoded) || !bcrypt5.NeedsRehash(encoded) {
+		t.Errorf("NeedsRehash() does not compare the bcrypt cost")
+	}
+	if !bcrypt4.NeedsRehash("not a hash") {
+		t.Errorf("NeedsRehash() of a malformed hash = false")
+	}
+}
+
+func TestNewHasherErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm Algorithm
+		opts      []Option
+		err       error
+	}{
+		{"unknown algorithm", 0, nil, ErrUnsupportedAlgorithm},
+		{"argon2id without passes", Argon2id, []Option{WithArgon2idParams(0, 64, 1)}, ErrInvalidParams},
+		{"argon2id with too little memory", Argon2id, []Option{WithArgon2idParams(1, 16, 4)}, ErrInvalidParams},
+		{"bcrypt cost", Bcrypt, []Option{WithBcryptCost(3)}, ErrInvalidParams},
+		{"scrypt cost", Scrypt, []Option{WithScryptParams(0, 8, 1)}, ErrInvalidParams},
+		{"scrypt r*p", Scrypt, []Option{WithScryptParams(4, 1<<15, 1<<15)}, ErrInvalidParams},
+		{"pbkdf2 iterations", PBKDF2, []Option{WithPbkdf2Params(hash.SHA256, 0)}, ErrInvalidParams},
+		{"argon2id memory limit", Argon2id, []Option{WithArgon2idParams(1, maxArgon2Memory+1, 1)}, ErrInvalidParams},
+		{"argon2id passes limit", Argon2id, []Option{WithArgon2idParams(maxArgon2Time+1, 64, 1)}, ErrInvalidParams},
+		{"bcrypt cost limit", Bcrypt, []Option{WithBcryptCost(maxBcryptCost + 1)}, ErrInvalidParams},
+		{"scrypt cost limit", Scrypt, []Option{WithScryptParams(maxScryptLogN+1, 1, 1)}, ErrInvalidParams},
+		{"scrypt memory limit", Scrypt, []Option{WithScryptParams(20, 16, 1)}, ErrInvalidParams},
+		{"scrypt parallelism limit", Scrypt, []Option{WithScryptParams(4, 8, maxScryptParallelism+1)}, ErrInvalidParams},
+		{"pbkdf2 iterations limit", PBKDF2, []Option{WithPbkdf2Params(hash.SHA256, maxPbkdf2Iterations+1)}, ErrInvalidParams},
+		{"long key", Scrypt, []Option{WithKeySize(maxKeySize + 1)}, ErrInvalidParams},
+		{"pbkdf2 shake", PBKDF2, []Option{WithPbkdf2Params(hash.SHAKE128, 1000)}, ErrInvalidParams},
+		{"short salt", Argon2id, []Option{WithSaltSize(4)}, ErrInvalidParams},
+		{"short key", Scrypt, []Option{WithKeySize(8)}, ErrInvalidParams},
+	}
+	for _, tt := range tests {
+		if _, err := NewHasher(tt.algorithm, tt.opts...); err != tt.err {
+			t.Errorf("%s: NewHasher() error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestVerifyErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+		err     error
+	}{
+		{"empty", "", ErrInvalidHash},
+		{"unknown algorithm", "$md5$c2FsdHNhbHRzYWx0c2FsdA$c2FsdHNhbHRzYWx0c2FsdA", ErrUnsupportedAlgorithm},
+		{"argon2i", "$argon2i$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$c2FsdHNhbHRzYWx0c2FsdA", ErrUnsupportedAlgorithm},
+		{"argon2 version", "$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$c2FsdHNhbHRzYWx0c2FsdA", ErrInvalidHash},
+		{"missing parameter", "$argon2id$v=19$m=64,t=1$c2FsdHNhbHRzYWx0c2FsdA$c2FsdHNhbHRzYWx0c2FsdA", ErrInvalidHash},
+		{"invalid parameter", "$scrypt$ln=0,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$c2FsdHNhbHRzYWx0c2FsdA", ErrInvalidParams},
+		{"argon2id memory limit", "$argon2id$v=19$m=4294967295,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$c2FsdHNhbHRzYWx0c2FsdA", ErrInvalidParams},
+		{"argon2id passes limit", "$argon2id$v=19$m=64,t=4294967295,p=1$c2FsdHNhbHRzYWx0c2FsdA$c2FsdHNhbHRzYWx0c2FsdA", ErrInvalidParams},
+		{"scrypt cost limit", "$scrypt$ln=62,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$c2FsdHNhbHRzYWx0c2FsdA", ErrInvalidParams},
+		{"scrypt block size limit", "$scrypt$ln=17,r=1048576,p=1$c2FsdHNhbHRzYWx0c2FsdA$c2FsdHNhbHRzYWx0c2FsdA", ErrInvalidParams},
+		{"pbkdf2 iterations limit", "$pbkdf2-sha256$i=9223372036854775807$c2FsdHNhbHRzYWx0c2FsdA$c2FsdHNhbHRzYWx0c2FsdA", ErrInvalidParams},
+		{"bcrypt cost limit", "$2a$31$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy", ErrInvalidParams},
+		{"unknown pbkdf2 hash", "$pbkdf2-md5$i=10$c2FsdHNhbHRzYWx0c2FsdA$c2FsdHNhbHRzYWx0c2FsdA", ErrUnsupportedAlgorithm},
+		{"bad salt", "$pbkdf2-sha256$i=10$!!!$c2FsdHNhbHRzYWx0c2FsdA", ErrInvalidHash},
+	}
+	for _, tt := range tests {
+		if err := Verify([]byte("password"), tt.encoded); err != tt.err {
+			t.Errorf("%s: Verify() error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package password
+
+// PHC string format
+// https://github.com/P-H-C/phc-string-format/blob/master/phc-sf-spec.md
+//
+//	$<id>[$v=<version>][$<param>=<value>(,<param>=<value>)*][$<salt>[$<hash>]]
+//
+// salt and hash are base64 without padding
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+)
+
+type phc struct {
+	id      string
+	version int // zero without version
+	params  []phcParam
+	salt    []byte
+	hash    []byte
+}
+
+type phcParam struct {
+	name  string
+	value int
+}
+
+var phcEncoding = base64.RawStdEncoding
+
+func (p *phc) String() string {
+	var b strings.Builder
+	b.WriteString("$" + p.id)
+	if p.version != 0 {
+		b.WriteString("$v=" + strconv.Itoa(p.version))
+	}
+	for i, param := range p.params {
+		if i == 0 {
+			b.WriteString("$")
+		} else {
+			b.WriteString(",")
+		}
+		b.WriteString(param.name + "=" + strconv.Itoa(param.value))
+	}
+	b.WriteString("$" + phcEncoding.EncodeToString(p.salt))
+	b.WriteString("$" + phcEncoding.EncodeToString(p.hash))
+	return b.String()
+}
+
+// param returns the value of a parameter, which must be present
+func (p *phc) param(name string) (int, error) {
+	for _, param := range p.params {
+		if param.name == name {
+			return param.value, nil
+		}
+	}
+	return 0, ErrInvalidHash
+}
+
+// parsePhc parses a PHC string with numeric parameters, salt and hash
+func parsePhc(encoded string) (*phc, error) {
+	fields := strings.Split(encoded, "$")
+	if len(fields) < 4 || fields[0] != "" || fields[1] == "" {
+		return nil, ErrInvalidHash
+	}
+	p := &phc{id: fields[1]}
+	fields = fields[2:]
+
+	if version, ok := strings.CutPrefix(fields[0], "v="); ok {
+		v, err := strconv.Atoi(version)
+		if err != nil || v <= 0 {
+			return nil, ErrInvalidHash
+		}
+		p.version = v
+		fields = fields[1:]
+	}
+	switch len(fields) {
+	case 2:
+	case 3:
+		for _, param := range strings.Split(fields[0], ",") {
+			name, value, ok := strings.Cut(param, "=")
+			if !ok {
+				return nil, ErrInvalidHash
+			}
+			v, err := strconv.Atoi(value)
+			if err != nil || v < 0 {
+				return nil, ErrInvalidHash
+			}
+			p.params = append(p.params, phcParam{name: name, value: v})
+		}
+		fields = fields[1:]
+	default:
+		return nil, ErrInvalidHash
+	}
+
+	var err error
+	if p.salt, err = phcEncoding.DecodeString(fields[0]); err != nil {
+		return nil, ErrInvalidHash
+	}
+	if p.hash, err = phcEncoding.DecodeString(fields[1]); err != nil {
+		return nil, ErrInvalidHash
+	}
+	if len(p.salt) == 0 || len(p.hash) == 0 {
+		return nil, ErrInvalidHash
+	}
+	return p, nil
+}
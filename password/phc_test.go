@@ -0,0 +1,52 @@
+package password
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParsePhc(t *testing.T) {
+	p, err := parsePhc("$argon2id$v=19$m=65536,t=3,p=4$c29tZXNhbHQ$aGFzaA")
+	if err != nil {
+		t.Fatalf("parsePhc() error = %v", err)
+	}
+	if p.id != "argon2id" || p.version != 19 || len(p.params) != 3 {
+		t.Errorf("parsePhc() = %+v", p)
+	}
+	if m, _ := p.param("m"); m != 65536 {
+		t.Errorf("param(m) = %d, want 65536", m)
+	}
+	if !bytes.Equal(p.salt, []byte("somesalt")) || !bytes.Equal(p.hash, []byte("hash")) {
+		t.Errorf("parsePhc() salt = %q, hash = %q", p.salt, p.hash)
+	}
+	if s := p.String(); s != "$argon2id$v=19$m=65536,t=3,p=4$c29tZXNhbHQ$aGFzaA" {
+		t.Errorf("String() = %s", s)
+	}
+
+	p, err = parsePhc("$custom$c29tZXNhbHQ$aGFzaA")
+	if err != nil || p.id != "custom" || p.version != 0 || len(p.params) != 0 {
+		t.Errorf("parsePhc() without parameters = %+v, %v", p, err)
+	}
+	if s := p.String(); s != "$custom$c29tZXNhbHQ$aGFzaA" {
+		t.Errorf("String() = %s", s)
+	}
+}
+
+func TestParsePhcErrors(t *testing.T) {
+	for _, encoded := range []string{
+		"",
+		"argon2id$v=19$m=1$c29tZXNhbHQ$aGFzaA",
+		"$$v=19$m=1$c29tZXNhbHQ$aGFzaA",
+		"$argon2id$v=x$m=1$c29tZXNhbHQ$aGFzaA",
+		"$argon2id$v=19$m$c29tZXNhbHQ$aGFzaA",
+		"$argon2id$v=19$m=-1$c29tZXNhbHQ$aGFzaA",
+		"$argon2id$v=19$m=1$c29tZXNhbHQ",
+		"$argon2id$v=19$m=1$c29tZXNhbHQ=$aGFzaA",
+		"$argon2id$v=19$m=1$$aGFzaA",
+		"$argon2id$v=19$m=1$a$b$c29tZXNhbHQ$aGFzaA",
+	} {
+		if _, err := parsePhc(encoded); err != ErrInvalidHash {
+			t.Errorf("parsePhc(%q) error = %v, want %v", encoded, err, ErrInvalidHash)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// It implements AES-CMAC message authentication code
+// https://datatracker.ietf.org/doc/html/rfc4493 (NIST SP 800-38B)
+package signature
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+)
+
+// errors
+var (
+	// ErrInvalidMac indicates that a message authentication code does not match the data
+	ErrInvalidMac = errors.New("invalid message authentication code")
+)
+
+// Cmac calculates and verifies AES-CMAC, the key size selects AES-128, AES-192 or AES-256
+type Cmac struct {
+	block cipher.Block
+}
+
+// NewCmac returns an AES-CMAC signer/verifier for a 16, 24 or 32 byte key
+func NewCmac(key []byte) (*Cmac, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Cmac{block: block}, nil
+}
+
+// Sign returns the 16 byte CMAC of data
+func (x *Cmac) Sign(data []byte) ([]byte, error) {
+	return cmac(x.block, data), nil
+}
+
+// VerifySignature checks mac against data in constant time
+func (x *Cmac) VerifySignature(data, mac []byte) error {
+	if subtle.ConstantTimeCompare(cmac(x.block, data), mac) != 1 {
+		return ErrInvalidMac
+	}
+	return nil
+}
+
+// calculates AES-CMAC of data and returns it in hex
+func CalculateCmac(key, data []byte) (string, error) {
+	x, err := NewCmac(key)
+	if err != nil {
+		return "", err
+	}
+	mac, err := x.Sign(data)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(mac), nil
+}
+
+// cmac implements the MAC generation algorithm of RFC 4493 section 2.4
+func cmac(block cipher.Block, data []byte) []byte {
+	k1, k2 := cmacSubkeys(block)
+
+	n := (len(data) + aes.BlockSize - 1) / aes.BlockSize
+	complete := n > 0 && len(data)%aes.BlockSize == 0
+	if n == 0 {
+		n = 1
+	}
+
+	last := make([]byte, aes.BlockSize)
+	if complete {
+		copy(last, data[(n-1)*aes.BlockSize:])
+		xorBytes(last, last, k1)
+	} else {
+		rest := copy(last, data[(n-1)*aes.BlockSize:])
+		last[rest] = 0x80
+		xorBytes(last, last, k2)
+	}
+
+	x := make([]byte, aes.BlockSize)
+	for i := 0; i < n-1; i++ {
+		xorBytes(x, x, data[i*aes.BlockSize:(i+1)*aes.BlockSize])
+		block.Encrypt(x, x)
+	}
+	xorBytes(x, x, last)
+	block.Encrypt(x, x)
+	return x
+}
+
+// cmacSubkeys implements the subkey generation algorithm of RFC 4493 section 2.3
+func cmacSubkeys(block cipher.Block) ([]byte, []byte) {
+	l := make([]byte, aes.BlockSize)
+	block.Encrypt(l, l)
+	k1 := shiftLeft(l)
+	k2 := shiftLeft(k1)
+	return k1, k2
+}
+
+// shiftLeft doubles src in GF(2^128) as defined for CMAC subkeys
+func shiftLeft(src []byte) []byte {
+	dst := make([]byte, len(src))
+	var carry byte
+	for i := len(src) - 1; i >= 0; i-- {
+		dst[i] = src[i]<<1 | carry
+		carry = src[i] >> 7
+	}
+	// constant-time conditional xor with R_128 = 0^120 || 10000111
+	dst[len(dst)-1] ^= 0x87 & -carry
+	return dst
+}
+
+// xorBytes sets dst[i] = a[i] ^ b[i] for every byte of dst
+func xorBytes(dst, a, b []byte) {
+	for i := range dst {
+		dst[i] = a[i] ^ b[i]
+	}
+}
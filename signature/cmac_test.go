@@ -0,0 +1,56 @@
+package signature
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// Tests from RFC 4493 section 4 and NIST SP 800-38B appendix D
+// https://datatracker.ietf.org/doc/html/rfc4493#section-4
+var cmacMessage = "6bc1bee22e409f96e93d7e117393172a" +
+	"ae2d8a571e03ac9c9eb76fac45af8e51" +
+	"30c81c46a35ce411e5fbc1191a0a52ef" +
+	"f69f2445df4f9b17ad2b417be66c3710"
+
+var cmacTestCases = []struct {
+	key    string
+	length int
+	mac    string
+}{
+	{key: "2b7e151628aed2a6abf7158809cf4f3c", length: 0, mac: "bb1d6929e95937287fa37d129b756746"},
+	{key: "2b7e151628aed2a6abf7158809cf4f3c", length: 16, mac: "070a16b46b4d4144f79bdd9dd04a287c"},
+	{key: "2b7e151628aed2a6abf7158809cf4f3c", length: 40, mac: "dfa66747de9ae63030ca32611497c827"},
+	{key: "2b7e151628aed2a6abf7158809cf4f3c", length: 64, mac: "51f0bebf7e3b9d92fc49741779363cfe"},
+	{key: "8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b", length: 0, mac: "d17ddf46adaacde531cac483de7a9367"},
+	{key: "8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b", length: 16, mac: "9e99a7bf31e710900662f65e617c5184"},
+	{key: "8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b", length: 40, mac: "8a1de5be2eb31aad089a82e6ee908b0e"},
+	{key: "8e73b0f7da0e6452c810f32b809079e562f8ead2522c6b7b", length: 64, mac: "a1d5df0eed790f794d77589659f39a11"},
+	{key: "603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4", length: 0, mac: "028962f61b7bf89efc6b551f4667d983"},
+	{key: "603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4", length: 16, mac: "28a7023f452e8f82bd4bf28d8c37c35c"},
+	{key: "603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4", length: 40, mac: "aaf3d8f1de5640c232f5b169b9c911e6"},
+	{key: "603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4", length: 64, mac: "e1992190549f6ed5696a2c056c315410"},
+}
+
+func TestCmac(t *testing.T) {
+	message, _ := hex.DecodeString(cmacMessage)
+	for i, test := range cmacTestCases {
+		key, _ := hex.DecodeString(test.key)
+		digest, err := CalculateCmac(key, message[:test.length])
+		if err != nil {
+			t.Errorf("TEST %d:: Error calculating cmac: %s", i, err)
+		}
+		if digest != test.mac {
+			t.Errorf("TEST %d:: Cmac digest mismatch: %s != %s", i, digest, test.mac)
+		}
+
+		x, _ := NewCmac(key)
+		mac, _ := hex.DecodeString(test.mac)
+		if err := x.VerifySignature(message[:test.length], mac); err != nil {
+			t.Errorf("TEST %d:: VerifySignature() error = %v", i, err)
+		}
+		mac[0] ^= 1
+		if err := x.VerifySignature(message[:test.length], mac); err != ErrInvalidMac {
+			t.Errorf("TEST %d:: VerifySignature() error = %v, want %v", i, err, ErrInvalidMac)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// It implements GMAC message authentication code, the authentication-only variant of GCM
+// with an empty plaintext (NIST SP 800-38D)
+package signature
+
+import (
+	"crypto/aes"
+	gocipher "crypto/cipher"
+	"errors"
+
+	"github.com/priyanshujain/crypto/cipher"
+)
+
+// errors
+var (
+	// ErrInvalidNonceSize indicates a GMAC nonce which is not 96 bits long
+	ErrInvalidNonceSize = errors.New("invalid gmac nonce size")
+)
+
+// Gmac calculates and verifies GMAC
+type Gmac struct {
+	aead  gocipher.AEAD
+	nonce cipher.NonceGenerator
+}
+
+// NewGmac returns a GMAC signer/verifier for a 16, 24 or 32 byte key.
+// nonce is used by Sign, nil uses random 96-bit nonces.
+func NewGmac(key []byte, nonce cipher.NonceGenerator) (*Gmac, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := gocipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if nonce == nil {
+		nonce = cipher.NewRandomNonce(0)
+	}
+	return &Gmac{aead: aead, nonce: nonce}, nil
+}
+
+// Sign returns nonce || tag for data with a fresh nonce
+func (x *Gmac) Sign(data []byte) ([]byte, error) {
+	nonce, err := x.nonce.Nonce()
+	if err != nil {
+		return nil, err
+	}
+	tag, err := x.SignWithNonce(nonce, data)
+	if err != nil {
+		return nil, err
+	}
+	return append(nonce, tag...), nil
+}
+
+// SignWithNonce returns the 16 byte GMAC tag of data for an explicit 96-bit nonce.
+// A nonce must never be used twice with the same key.
+func (x *Gmac) SignWithNonce(nonce, data []byte) ([]byte, error) {
+	if len(nonce) != x.aead.NonceSize() {
+		return nil, ErrInvalidNonceSize
+	}
+	return x.aead.Seal(nil, nonce, nil, data), nil
+}
+
+// VerifySignature checks a nonce || tag value produced by Sign in constant time
+func (x *Gmac) VerifySignature(data, mac []byte) error {
+	if len(mac) != x.aead.NonceSize()+x.aead.Overhead() {
+		return ErrInvalidMac
+	}
+	nonce, tag := mac[:x.aead.NonceSize()], mac[x.aead.NonceSize():]
+	if _, err := x.aead.Open(nil, nonce, tag, data); err != nil {
+		return ErrInvalidMac
+	}
+	return nil
+}
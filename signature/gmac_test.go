@@ -0,0 +1,70 @@
+package signature
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+var gmacTestCases = []struct {
+	key   string
+	nonce string
+	data  string
+	tag   string
+}{
+	// GCM test case 1 from "The Galois/Counter Mode of Operation (GCM)", McGrew & Viega
+	{
+		key:   "00000000000000000000000000000000",
+		nonce: "000000000000000000000000",
+		data:  "",
+		tag:   "58e2fccefa7e3061367f1d57a4e7455a",
+	},
+	// IEEE 802.1AE-2006 annex C, 2.1.1 54-byte packet authentication using GCM-AES-128
+	{
+		key:   "ad7a2bd03eac835a6f620fdcb506b345",
+		nonce: "12153524c0895e81b2c28465",
+		data: "d609b1f056637a0d46df998d88e5222ab2c2846512153524c0895e81" +
+			"08000f101112131415161718191a1b1c1d1e1f202122232425262728" +
+			"292a2b2c2d2e2f30313233340001",
+		tag: "f09478a9b09007d06f46e9b6a1da25dd",
+	},
+}
+
+func TestGmac(t *testing.T) {
+	for i, test := range gmacTestCases {
+		key, _ := hex.DecodeString(test.key)
+		nonce, _ := hex.DecodeString(test.nonce)
+		data, _ := hex.DecodeString(test.data)
+		x, err := NewGmac(key, nil)
+		if err != nil {
+			t.Fatalf("TEST %d:: NewGmac() error = %v", i, err)
+		}
+		tag, err := x.SignWithNonce(nonce, data)
+		if err != nil {
+			t.Errorf("TEST %d:: Error calculating gmac: %s", i, err)
+		}
+		if hex.EncodeToString(tag) != test.tag {
+			t.Errorf("TEST %d:: Gmac tag mismatch: %x != %s", i, tag, test.tag)
+		}
+		if err := x.VerifySignature(data, append(nonce, tag...)); err != nil {
+			t.Errorf("TEST %d:: VerifySignature() error = %v", i, err)
+		}
+	}
+}
+
+func TestGmacSign(t *testing.T) {
+	x, _ := NewGmac(make([]byte, 32), nil)
+	data := []byte("what do ya want for nothing?")
+	mac, err := x.Sign(data)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if err := x.VerifySignature(data, mac); err != nil {
+		t.Errorf("VerifySignature() error = %v", err)
+	}
+	if err := x.VerifySignature(data[1:], mac); err != ErrInvalidMac {
+		t.Errorf("VerifySignature() error = %v, want %v", err, ErrInvalidMac)
+	}
+	if _, err := x.SignWithNonce(make([]byte, 16), data); err != ErrInvalidNonceSize {
+		t.Errorf("SignWithNonce() error = %v, want %v", err, ErrInvalidNonceSize)
+	}
+}
@@ -2,18 +2,17 @@ package signature
 
 import (
 	"crypto/hmac"
-	"crypto/sha1"
-	"crypto/sha256"
-	"crypto/sha512"
 	"encoding/hex"
 	"errors"
 	"fmt"
-	"hash"
-	"strings"
+	gohash "hash"
+
+	"github.com/priyanshujain/crypto/hash"
 )
 
 // calculates HMAC signature based on a key and digest algorithm
-// supported digest algorithms are: SHA1, 256, 512
+// supported digest algorithms are: SHA1, SHA224, SHA256, SHA384, SHA512, SHA512/224, SHA512/256
+// and SHA3-224, SHA3-256, SHA3-384, SHA3-512, and algorithms added with hash.Register
 func CalculateHmac(key, data []byte, algorithm string) (string, error) {
 	digestFunc := getDigestFunc(algorithm)
 	if digestFunc == nil {
@@ -25,19 +24,21 @@ func CalculateHmac(key, data []byte, algorithm string) (string, error) {
 	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
-// returns a hash function based on a digest algorithm
-// we are intentionally not allowing mds here because it is severely compromised
-// https://datatracker.ietf.org/doc/html/rfc6151
-func getDigestFunc(algorithm string) func() hash.Hash {
-	algorithm = strings.ToLower(algorithm)
-	switch algorithm {
-	case "sha256":
-		return sha256.New
-	case "sha1":
-		return sha1.New
-	case "sha512":
-		return sha512.New
-	default:
+// returns a hash function based on a digest algorithm of the hash registry. MD5 is not built in
+// because it is severely compromised (https://datatracker.ietf.org/doc/html/rfc6151), but an
+// application which registers it with hash.Register can use it, like any registered algorithm
+func getDigestFunc(algorithm string) func() gohash.Hash {
+	htype, err := hash.ParseHashType(algorithm)
+	if err != nil {
+		return nil
+	}
+	// extendable-output functions are not meant for HMAC, KMAC is their MAC
+	if htype == hash.SHAKE128 || htype == hash.SHAKE256 {
+		return nil
+	}
+	newFunc, err := hash.NewFunc(htype)
+	if err != nil {
 		return nil
 	}
+	return newFunc
 }
@@ -1,7 +1,11 @@
 package signature
 
 import (
+	"crypto"
+	"crypto/sha256"
 	"testing"
+
+	"github.com/priyanshujain/crypto/hash"
 )
 
 var hmacTestCases = []struct {
@@ -56,6 +60,49 @@ var hmacTestCases = []struct {
 		data:      []byte("what do ya want for nothing?"),
 		digest:    "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
 	},
+	// RFC 4231 test case 2, SHA-512/224 and SHA-512/256 computed with openssl
+	{
+		key:       []byte("Jefe"),
+		algorithm: "SHA224",
+		data:      []byte("what do ya want for nothing?"),
+		digest:    "a30e01098bc6dbbf45690f3a7e9e6d0f8bbea2a39e6148008fd05e44",
+	},
+	{
+		key:       []byte("Jefe"),
+		algorithm: "SHA-384",
+		data:      []byte("what do ya want for nothing?"),
+		digest:    "af45d2e376484031617f78d2b58a6b1b9c7ef464f5a01b47e42ec3736322445e8e2240ca5e69e2c78b3239ecfab21649",
+	},
+	{
+		key:       []byte("Jefe"),
+		algorithm: "sha512",
+		data:      []byte("what do ya want for nothing?"),
+		digest:    "164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea2505549758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737",
+	},
+	{
+		key:       []byte("Jefe"),
+		algorithm: "SHA-512/224",
+		data:      []byte("what do ya want for nothing?"),
+		digest:    "4a530b31a79ebcce36916546317c45f247d83241dfb818fd37254bde",
+	},
+	{
+		key:       []byte("Jefe"),
+		algorithm: "sha512_256",
+		data:      []byte("what do ya want for nothing?"),
+		digest:    "6df7b24630d5ccb2ee335407081a87188c221489768fa2020513b2d593359456",
+	},
+	{
+		key:       []byte("Jefe"),
+		algorithm: "SHA3-256",
+		data:      []byte("what do ya want for nothing?"),
+		digest:    "c7d4072e788877ae3596bbb0da73b887c9171f93095b294ae857fbe2645e1ba5",
+	},
+	{
+		key:       []byte("Jefe"),
+		algorithm: "sha3_512",
+		data:      []byte("what do ya want for nothing?"),
+		digest:    "5a4bfeab6166427c7a3647b747292b8384537cdb89afb3bf5665e4c5e709350b287baec921fd7ca0ee7a0c31d022a95e1fc92ba9d77df883960275beb4e62024",
+	},
 }
 
 // Test hmac calculation
@@ -70,3 +117,36 @@ func TestHmac(t *testing.T) {
 		}
 	}
 }
+
+// registerSHA256 adds SHA256 to the global hash registry for the duration of the test
+func registerSHA256(t *testing.T) hash.HashType {
+	t.Helper()
+	htype, err := hash.Register("sha256-registered", sha256.New, hash.WithCryptoHash(crypto.SHA256))
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	t.Cleanup(func() {
+		if err := hash.Unregister(htype); err != nil {
+			t.Errorf("Unregister() error = %v", err)
+		}
+	})
+	return htype
+}
+
+// Test hmac with an algorithm added to the hash registry
+func TestHmacRegisteredHash(t *testing.T) {
+	registerSHA256(t)
+	digest, err := CalculateHmac([]byte("key"), []byte("data"), "SHA256_REGISTERED")
+	if err != nil {
+		t.Fatalf("CalculateHmac() error = %v", err)
+	}
+	want, _ := CalculateHmac([]byte("key"), []byte("data"), "SHA256")
+	if digest != want {
+		t.Errorf("CalculateHmac() = %s, want %s", digest, want)
+	}
+	for _, algorithm := range []string{"SHAKE128", "md5"} {
+		if _, err := CalculateHmac([]byte("key"), []byte("data"), algorithm); err == nil {
+			t.Errorf("CalculateHmac() with %s succeeded", algorithm)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package signature
+
+// signing of JSON documents in their RFC 8785 canonical form, so the signature does not
+// depend on key order, whitespace or number formatting
+
+import (
+	"github.com/priyanshujain/crypto/jcs"
+)
+
+// CalculateJSONHmac calculates the HMAC of the canonical form of a JSON document in hex
+func CalculateJSONHmac(key, data []byte, algorithm string) (string, error) {
+	canonical, err := jcs.Canonicalize(data)
+	if err != nil {
+		return "", err
+	}
+	return CalculateHmac(key, canonical, algorithm)
+}
+
+// SignJSON hashes the canonical form of a JSON document and signs it
+func (x *Rsa) SignJSON(data []byte) ([]byte, error) {
+	hashed, err := jcs.Hash(x.hash, data)
+	if err != nil {
+		return nil, err
+	}
+	return x.Sign(hashed)
+}
+
+// VerifyJSON verifies a signature of SignJSON
+func (x *Rsa) VerifyJSON(data, signature []byte) error {
+	hashed, err := jcs.Hash(x.hash, data)
+	if err != nil {
+		return err
+	}
+	return x.VerifySignature(hashed, signature)
+}
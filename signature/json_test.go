@@ -0,0 +1,54 @@
+package signature
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/priyanshujain/crypto/hash"
+	"github.com/priyanshujain/crypto/jcs"
+	"github.com/priyanshujain/crypto/keystore"
+)
+
+// the same document from two producers
+var (
+	jsonDocument    = []byte(`{"amount": 10.50, "to": "alice", "tags": ["a", "b"]}`)
+	jsonReformatted = []byte("{\n  \"tags\": [\"a\",\"b\"],\n  \"to\": \"\\u0061lice\",\n  \"amount\": 1.05e1\n}")
+)
+
+func TestJSONHmac(t *testing.T) {
+	mac, err := CalculateJSONHmac([]byte("key"), jsonDocument, "SHA256")
+	if err != nil {
+		t.Fatalf("CalculateJSONHmac() error = %v", err)
+	}
+	want, _ := CalculateHmac([]byte("key"), []byte(`{"amount":10.5,"tags":["a","b"],"to":"alice"}`), "SHA256")
+	if mac != want {
+		t.Errorf("CalculateJSONHmac() = %s, want %s", mac, want)
+	}
+	other, _ := CalculateJSONHmac([]byte("key"), jsonReformatted, "SHA256")
+	if other != mac {
+		t.Errorf("CalculateJSONHmac() depends on the formatting")
+	}
+	if _, err := CalculateJSONHmac([]byte("key"), []byte(`{"a":1,"a":2}`), "SHA256"); !errors.Is(err, jcs.ErrDuplicateKey) {
+		t.Errorf("CalculateJSONHmac() error = %v, want %v", err, jcs.ErrDuplicateKey)
+	}
+}
+
+func TestRsaSignJSON(t *testing.T) {
+	rsaPrivateKey, _ := keystore.ParsePrivateKeyFromPem([]byte(privateKeyPem))
+	for _, scheme := range []RsaScheme{PKCS1, PSS} {
+		rsa := Rsa{privateKey: rsaPrivateKey, publicKey: rsaPrivateKey.PublicKey(), hash: hash.SHA256, scheme: scheme}
+		sig, err := rsa.SignJSON(jsonDocument)
+		if err != nil {
+			t.Fatalf("SignJSON() error = %v", err)
+		}
+		if err := rsa.VerifyJSON(jsonReformatted, sig); err != nil {
+			t.Errorf("VerifyJSON() of the reformatted document error = %v", err)
+		}
+		if err := rsa.VerifyJSON([]byte(`{"amount":10.5,"tags":["a","b"],"to":"mallory"}`), sig); err == nil {
+			t.Errorf("VerifyJSON() of another document succeeded")
+		}
+		if _, err := rsa.SignJSON([]byte(`{`)); !errors.Is(err, jcs.ErrInvalidJSON) {
+			t.Errorf("SignJSON() error = %v, want %v", err, jcs.ErrInvalidJSON)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// It implements KMAC128 and KMAC256 message authentication codes
+// https://nvlpubs.nist.gov/nistpubs/SpecialPublications/NIST.SP.800-185.pdf
+package signature
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/priyanshujain/crypto/hash"
+)
+
+// errors
+var (
+	// ErrInvalidMacSize indicates a KMAC output size which is not positive
+	ErrInvalidMacSize = errors.New("invalid mac size")
+)
+
+// Kmac calculates and verifies KMAC, a keyed cSHAKE with a variable output size
+type Kmac struct {
+	xof           hash.HashType // SHAKE128 for KMAC128, SHAKE256 for KMAC256
+	key           []byte
+	customization []byte
+	size          int
+}
+
+// NewKmac128 returns a KMAC128 signer/verifier producing size byte macs,
+// the customization string may be empty
+func NewKmac128(key, customization []byte, size int) (*Kmac, error) {
+	return newKmac(hash.SHAKE128, key, customization, size)
+}
+
+// NewKmac256 returns a KMAC256 signer/verifier producing size byte macs,
+// the customization string may be empty
+func NewKmac256(key, customization []byte, size int) (*Kmac, error) {
+	return newKmac(hash.SHAKE256, key, customization, size)
+}
+
+func newKmac(xof hash.HashType, key, customization []byte, size int) (*Kmac, error) {
+	if size <= 0 {
+		return nil, ErrInvalidMacSize
+	}
+	return &Kmac{xof: xof, key: key, customization: customization, size: size}, nil
+}
+
+// Sign returns the KMAC of data
+func (x *Kmac) Sign(data []byte) ([]byte, error) {
+	h, err := hash.NewCSHAKE(x.xof, []byte("KMAC"), x.customization)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(hash.BytePad(hash.EncodeString(x.key), h.BlockSize()))
+	h.Write(data)
+	h.Write(hash.RightEncode(uint64(x.size) * 8))
+	mac := make([]byte, x.size)
+	h.Read(mac)
+	return mac, nil
+}
+
+// VerifySignature checks mac against data in constant time
+func (x *Kmac) VerifySignature(data, mac []byte) error {
+	expected, err := x.Sign(data)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(expected, mac) != 1 {
+		return ErrInvalidMac
+	}
+	return nil
+}
+
+// calculates KMAC of data without customization and returns it in hex,
+// supported algorithms are KMAC128 (32 byte mac) and KMAC256 (64 byte mac)
+func CalculateKmac(key, data []byte, algorithm string) (string, error) {
+	var x *Kmac
+	var err error
+	switch strings.ToLower(algorithm) {
+	case "kmac128":
+		x, err = NewKmac128(key, nil, 32)
+	case "kmac256":
+		x, err = NewKmac256(key, nil, 64)
+	default:
+		return "", fmt.Errorf("unsupported kmac algorithm: %s", algorithm)
+	}
+	if err != nil {
+		return "", err
+	}
+	mac, err := x.Sign(data)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(mac), nil
+}
@@ -0,0 +1,104 @@
+package signature
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+func sequence(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(i)
+	}
+	return b
+}
+
+// NIST SP 800-185 KMAC samples
+// https://csrc.nist.gov/CSRC/media/Projects/Cryptographic-Standards-and-Guidelines/documents/examples/KMAC_samples.pdf
+var kmacTestCases = []struct {
+	kmac          int
+	data          []byte
+	customization string
+	mac           string
+}{
+	{
+		kmac: 128,
+		data: sequence(4),
+		mac:  "e5780b0d3ea6f7d3a429c5706aa43a00fadbd7d49628839e3187243f456ee14e",
+	},
+	{
+		kmac:          128,
+		data:          sequence(4),
+		customization: "My Tagged Application",
+		mac:           "3b1fba963cd8b0b59e8c1a6d71888b7143651af8ba0a7070c0979e2811324aa5",
+	},
+	{
+		kmac:          128,
+		data:          sequence(200),
+		customization: "My Tagged Application",
+		mac:           "1f5b4e6cca02209e0dcb5ca635b89a15e271ecc760071dfd805faa38f9729230",
+	},
+	{
+		kmac:          256,
+		data:          sequence(4),
+		customization: "My Tagged Application",
+		mac: "20c570c31346f703c9ac36c61c03cb64c3970d0cfc787e9b79599d273a68d2f7" +
+			"f69d4cc3de9d104a351689f27cf6f5951f0103f33f4f24871024d9c27773a8dd",
+	},
+	{
+		kmac: 256,
+		data: sequence(200),
+		mac: "75358cf39e41494e949707927cee0af20a3ff553904c86b08f21cc414bcfd691" +
+			"589d27cf5e15369cbbff8b9a4c2eb17800855d0235ff635da82533ec6b759b69",
+	},
+	{
+		kmac:          256,
+		data:          sequence(200),
+		customization: "My Tagged Application",
+		mac: "b58618f71f92e1d56c1b8c55ddd7cd188b97b4ca4d99831eb2699a837da2e4d9" +
+			"70fbacfde50033aea585f1a2708510c32d07880801bd182898fe476876fc8965",
+	},
+}
+
+func TestKmac(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(0x40 + i)
+	}
+	for i, test := range kmacTestCases {
+		t.Run(fmt.Sprintf("KMAC%d: sample %d", test.kmac, i+1), func(t *testing.T) {
+			var x *Kmac
+			var err error
+			if test.kmac == 128 {
+				x, err = NewKmac128(key, []byte(test.customization), len(test.mac)/2)
+			} else {
+				x, err = NewKmac256(key, []byte(test.customization), len(test.mac)/2)
+			}
+			if err != nil {
+				t.Fatalf("NewKmac() error = %v", err)
+			}
+			mac, err := x.Sign(test.data)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if hex.EncodeToString(mac) != test.mac {
+				t.Errorf("Sign() = %x, want %s", mac, test.mac)
+			}
+			if err := x.VerifySignature(test.data, mac); err != nil {
+				t.Errorf("VerifySignature() error = %v", err)
+			}
+			mac[0] ^= 1
+			if err := x.VerifySignature(test.data, mac); err != ErrInvalidMac {
+				t.Errorf("VerifySignature() error = %v, want %v", err, ErrInvalidMac)
+			}
+		})
+	}
+	digest, err := CalculateKmac(key, sequence(4), "KMAC128")
+	if err != nil || digest != kmacTestCases[0].mac {
+		t.Errorf("CalculateKmac() = %s, %v, want %s", digest, err, kmacTestCases[0].mac)
+	}
+	if _, err := NewKmac256(key, nil, 0); err != ErrInvalidMacSize {
+		t.Errorf("NewKmac256() error = %v, want %v", err, ErrInvalidMacSize)
+	}
+}
@@ -2,9 +2,11 @@
 package signature
 
 import (
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"errors"
+	"io"
 
 	"github.com/priyanshujain/crypto/hash"
 	"github.com/priyanshujain/crypto/keystore"
@@ -21,6 +23,12 @@ const (
 // errors
 var (
 	ErrInvalidSignatureScheme = errors.New("invalid signature scheme")
+
+	// ErrMissingPrivateKey indicates a signer without a private key
+	ErrMissingPrivateKey = errors.New("missing rsa private key")
+
+	// ErrMissingPublicKey indicates a verifier without a public key
+	ErrMissingPublicKey = errors.New("missing rsa public key")
 )
 
 type Rsa struct {
@@ -30,8 +38,90 @@ type Rsa struct {
 	hash       hash.HashType
 }
 
+// RsaOption configures an Rsa created by NewRsaSigner or NewRsaVerifier
+type RsaOption func(*Rsa)
+
+// WithScheme sets the signature scheme, the default is PSS
+func WithScheme(scheme RsaScheme) RsaOption {
+	return func(x *Rsa) {
+		x.scheme = scheme
+	}
+}
+
+// WithHash sets the hash of the signed digests, the default is SHA256
+func WithHash(htype hash.HashType) RsaOption {
+	return func(x *Rsa) {
+		x.hash = htype
+	}
+}
+
+// RsaSigner signs with a private key. It implements crypto.Signer, and the embedded Rsa
+// signs digests of its own hash and scheme with Rsa.Sign and verifies signatures.
+type RsaSigner struct {
+	*Rsa
+}
+
+var _ crypto.Signer = (*RsaSigner)(nil)
+
+// NewRsaSigner returns an RsaSigner for the private key
+func NewRsaSigner(priv *keystore.PrivateKey, opts ...RsaOption) (*RsaSigner, error) {
+	if priv == nil {
+		return nil, ErrMissingPrivateKey
+	}
+	x, err := newRsa(priv, priv.PublicKey(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return &RsaSigner{Rsa: x}, nil
+}
+
+// NewRsaVerifier returns an Rsa which only holds a public key and can only verify
+func NewRsaVerifier(pub *keystore.PublicKey, opts ...RsaOption) (*Rsa, error) {
+	if pub == nil {
+		return nil, ErrMissingPublicKey
+	}
+	return newRsa(nil, pub, opts)
+}
+
+func newRsa(priv *keystore.PrivateKey, pub *keystore.PublicKey, opts []RsaOption) (*Rsa, error) {
+	x := &Rsa{
+		privateKey: priv,
+		publicKey:  pub,
+		scheme:     PSS,
+		hash:       hash.SHA256,
+	}
+	for _, opt := range opts {
+		opt(x)
+	}
+	if x.scheme != PKCS1 && x.scheme != PSS {
+		return nil, ErrInvalidSignatureScheme
+	}
+	if _, err := hash.GetStdCryptoHash(x.hash); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// Public returns the *rsa.PublicKey
+func (x *RsaSigner) Public() crypto.PublicKey {
+	return &x.publicKey.Key
+}
+
+// Sign signs a digest as crypto.Signer does: with PSS when opts is *rsa.PSSOptions and
+// otherwise with PKCS1 v1.5 and the hash of opts. A non-nil opts ignores the WithScheme and
+// WithHash options of NewRsaSigner, only a nil opts signs with them like Rsa.Sign.
+func (x *RsaSigner) Sign(random io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts == nil {
+		return x.Rsa.Sign(digest)
+	}
+	return x.privateKey.Sign(random, digest, opts)
+}
+
 // sign hashed data using private key
 func (x *Rsa) Sign(hashed []byte) ([]byte, error) {
+	if x.privateKey == nil {
+		return nil, ErrMissingPrivateKey
+	}
 	stdHash, err := hash.GetStdCryptoHash(x.hash)
 	if err != nil {
 		return nil, err
@@ -48,6 +138,9 @@ func (x *Rsa) Sign(hashed []byte) ([]byte, error) {
 
 // verify hashed data using public key
 func (x *Rsa) VerifySignature(hashed, signature []byte) error {
+	if x.publicKey == nil {
+		return ErrMissingPublicKey
+	}
 	stdHash, err := hash.GetStdCryptoHash(x.hash)
 	if err != nil {
 		return err
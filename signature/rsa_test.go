@@ -1,9 +1,16 @@
 package signature
 
 import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/hex"
 	"fmt"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/priyanshujain/crypto/hash"
 	"github.com/priyanshujain/crypto/keystore"
@@ -58,6 +65,55 @@ var rsaSignatureTestCases = []struct {
 		scheme: PKCS1,
 		htype:  hash.SHA1,
 	},
+	{
+		name:   "PKCS1-SHA224",
+		in:     "test",
+		out:    "1e567e59126b5c05829a639a8e93884a6ed83f92eea1acb3f6bee7ee24f54b13ed202cbc905cb0f43e52dbf164feaa4dd02f2265151568d3db490a13f5c2c8ab607e13a7fec18429e62f54804696c97e1d8bfc807d7f66a812cd3d8db59595978ce6801873481d533d3d7e130248a71fb2f581f15f2c2f88812380c1493076fcdc128fafe44d5b3a9a9ed294289026e3df01118cdbfe4c4e8a6dbb98b80a3b6276f3dcaa69e7a19a658336315676c7f77215f72ba3badd40e01168e006a5b91570bfbe71194359dfc6cade329d3af8eaf2613513f84cce8c904fa4f3d45883d7d320f7699337682e56e6c539d8b89d45274f3947e5a0a66667f8a409f6ad57b0",
+		scheme: PKCS1,
+		htype:  hash.SHA224,
+	},
+	{
+		name:   "PKCS1-SHA384",
+		in:     "test",
+		out:    "6a44aba332eacbbec88ed074927d77905f216c76060b3c0a09c9243bf8661e4f36f20ed118a87a86d444c20b22d5a6837eca20043f708dfe09521f385dc1508a029540339f91bc05437cc57521d6b14c3f5d7ce1dc197f3ad0e42eb4d812dcf121650c5031029a887fa5d32f11905561f54d88dfeba6825bd13e505335a2cebc6a25c968c919e692542625c1f662e97e23cd72fe3dad49b15dcf1f3795387706c421359fb2a23e41f582a58947494a73dc84206a3fbf9bbc6bd7052d390a5a915710b93e93f2b81665cb67b550600503a7b0552257890191e1e389caefb10510a2253029c59f05aee60acba73fd38ada3f3f4833d4179b0ccd97f8fe0c531169",
+		scheme: PKCS1,
+		htype:  hash.SHA384,
+	},
+	{
+		name:   "PKCS1-SHA512",
+		in:     "test",
+		out:    "6774bf61492b2ab8f74d4a1fa81772c8c7e0a92bbdb381af06488d9193b09205313552ba5184c44ddc6f1f6990a951f8fb05df8928653d94ee5a2872658047d009389cebbc6302682b32d65bf855e669bc8c261fd3b3ae07546dbec198c48626143941eb083ef6a290dfbd88d0506266e2bd664fc82943e910635b54f0ec2ade01072349c3b7b7ad02dd619e6318866d9ecbccf6d7c31820d29c95c26ad8d1e20208d57d87baaa39ac8a125307fc7ed7fbb984b112f431fcbb337df4f25036097ad134dc7bb567ce383721d5aea23f85226e3e12b6e1ad1570c44a3eb0bccf3da298fb51da5fe6e30ec5a0ae30b137b9a7c531eefae2c102aa85871228af7677",
+		scheme: PKCS1,
+		htype:  hash.SHA512,
+	},
+	{
+		name:   "PKCS1-SHA512/224",
+		in:     "test",
+		out:    "48a6b729a632e1be2e56aaad5264378aa7bad9ea2de2f3b0b86b47066c59ce6d0329ae820cdb585bcc5fc42f77bb80b4cb0fbc46babf188f0f0303a62dceec13e8c79a677430503e0ab94739b17f3872f167e780a0a91e961565dd49a27b085fbd1639d50d7efd1d5694b3c42ab4a7064bfebed0a9206c8c6f5bfc732a584b5b23497c0b304a3e1a267bcb29618fd3d1052f9d131fd6585b5ea7f37a26181ec9122751246fe88769b1728e416ae3066fa12cc76c14f6bb66cb3f4ea8bcc522736315267e19db36495c08d86f0b56bb31802842097c96b0f51440218e1d9edea42684883f336e7e680ff0715c09d18326364b2cd59331cd7b1bfca4dafde3c46a",
+		scheme: PKCS1,
+		htype:  hash.SHA512_224,
+	},
+	{
+		name:   "PKCS1-SHA512/256",
+		in:     "test",
+		out:    "3718310b10bde628d232cebd9e8d6ce3ee18391b287a6a1df36f5c79da26fce73e05c90367b26c47947a1b5089a8bb3bdf70df2ff0b9bd7e55b1ded2b2eedc856d15cb215948b6c8df6c10d148f1c8e369b3c5a787235edc576697b70f79c5f1f8a5f15e731eff151e47e99d8c1caceacbb785c080df50cc6ffe78a2e03dd0521215edca2299e4bddbe8427827f075bb5e8621550c0b3903a70abac781b8d93f0032253a530ebf0e36be6f558708198c77c37a7fb514ba8afabe4a2994f4ff5d04ba2c3e9768485568b3e8ebe349f1dd602b0e6380aa61bf2e1e0ec1d779f4f03fe2f1deb9129f14a0c27aa2da80aa92eb4bd95207f3d5110fa8a70f133dd159",
+		scheme: PKCS1,
+		htype:  hash.SHA512_256,
+	},
+	{
+		name:   "PSS-SHA384",
+		in:     "This is a PSS sign test",
+		out:    "",
+		scheme: PSS,
+		htype:  hash.SHA384,
+	},
+	{
+		name:   "PSS-SHA512",
+		in:     "This is a PSS sign test",
+		out:    "",
+		scheme: PSS,
+		htype:  hash.SHA512,
+	},
 	{
 		name:   "PSS-SHA1",
 		in:     "This is a PSS sign test",
@@ -120,3 +176,110 @@ func TestPSSRsaSignature(t *testing.T) {
 		})
 	}
 }
+
+func TestNewRsaSigner(t *testing.T) {
+	rsaPrivateKey, _ := keystore.ParsePrivateKeyFromPem([]byte(privateKeyPem))
+	for _, test := range rsaSignatureTestCases {
+		signer, err := NewRsaSigner(rsaPrivateKey, WithScheme(test.scheme), WithHash(test.htype))
+		if err != nil {
+			t.Fatalf("NewRsaSigner() error = %v", err)
+		}
+		verifier, err := NewRsaVerifier(rsaPrivateKey.PublicKey(), WithScheme(test.scheme), WithHash(test.htype))
+		if err != nil {
+			t.Fatalf("NewRsaVerifier() error = %v", err)
+		}
+		digest, _ := hash.Hash(test.htype, []byte(test.in))
+		sig, err := signer.Rsa.Sign(digest)
+		if err != nil {
+			t.Fatalf("%s: Sign() error = %v", test.name, err)
+		}
+		if test.scheme == PKCS1 && hex.EncodeToString(sig) != test.out {
+			t.Errorf("%s: Sign() = %x, want %s", test.name, sig, test.out)
+		}
+		if err := verifier.VerifySignature(digest, sig); err != nil {
+			t.Errorf("%s: VerifySignature() error = %v", test.name, err)
+		}
+		if _, err := verifier.Sign(digest); err != ErrMissingPrivateKey {
+			t.Errorf("%s: Sign() with a verifier error = %v, want %v", test.name, err, ErrMissingPrivateKey)
+		}
+	}
+
+	if _, err := NewRsaSigner(nil); err != ErrMissingPrivateKey {
+		t.Errorf("NewRsaSigner(nil) error = %v, want %v", err, ErrMissingPrivateKey)
+	}
+	if _, err := NewRsaVerifier(nil); err != ErrMissingPublicKey {
+		t.Errorf("NewRsaVerifier(nil) error = %v, want %v", err, ErrMissingPublicKey)
+	}
+	if _, err := NewRsaSigner(rsaPrivateKey, WithScheme(0)); err != ErrInvalidSignatureScheme {
+		t.Errorf("NewRsaSigner() error = %v, want %v", err, ErrInvalidSignatureScheme)
+	}
+	if _, err := NewRsaVerifier(rsaPrivateKey.PublicKey(), WithHash(hash.SHAKE128)); err != hash.ErrInvalidHashType {
+		t.Errorf("NewRsaVerifier() error = %v, want %v", err, hash.ErrInvalidHashType)
+	}
+}
+
+func TestRsaRegisteredHash(t *testing.T) {
+	registeredSHA256 := registerSHA256(t)
+	rsaPrivateKey, _ := keystore.ParsePrivateKeyFromPem([]byte(privateKeyPem))
+	signer, err := NewRsaSigner(rsaPrivateKey, WithHash(registeredSHA256))
+	if err != nil {
+		t.Fatalf("NewRsaSigner() error = %v", err)
+	}
+	digest, _ := hash.Hash(registeredSHA256, []byte("registered"))
+	sig, err := signer.Rsa.Sign(digest)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if err := rsa.VerifyPSS(&rsaPrivateKey.Key.PublicKey, crypto.SHA256, digest, sig, nil); err != nil {
+		t.Errorf("VerifyPSS() error = %v", err)
+	}
+}
+
+func TestRsaSignerCryptoSigner(t *testing.T) {
+	rsaPrivateKey, _ := keystore.ParsePrivateKeyFromPem([]byte(privateKeyPem))
+	signer, _ := NewRsaSigner(rsaPrivateKey, WithScheme(PKCS1), WithHash(hash.SHA512))
+
+	if pub, ok := signer.Public().(*rsa.PublicKey); !ok || !pub.Equal(&rsaPrivateKey.Key.PublicKey) {
+		t.Errorf("Public() = %v", signer.Public())
+	}
+	digest, _ := hash.Hash(hash.SHA384, []byte("crypto.Signer"))
+	sig, err := signer.Sign(rand.Reader, digest, crypto.SHA384)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if err := rsa.VerifyPKCS1v15(&rsaPrivateKey.Key.PublicKey, crypto.SHA384, digest, sig); err != nil {
+		t.Errorf("Sign() with crypto.SHA384 is not PKCS1 v1.5: %v", err)
+	}
+	// opts take precedence over the PKCS1 scheme and SHA512 of NewRsaSigner
+	sig, _ = signer.Sign(rand.Reader, digest, &rsa.PSSOptions{Hash: crypto.SHA384})
+	if err := rsa.VerifyPSS(&rsaPrivateKey.Key.PublicKey, crypto.SHA384, digest, sig, nil); err != nil {
+		t.Errorf("Sign() with rsa.PSSOptions is not PSS: %v", err)
+	}
+	// without opts the scheme and hash of NewRsaSigner
+	digest, _ = hash.Hash(hash.SHA512, []byte("crypto.Signer"))
+	sig, _ = signer.Sign(rand.Reader, digest, nil)
+	if err := rsa.VerifyPKCS1v15(&rsaPrivateKey.Key.PublicKey, crypto.SHA512, digest, sig); err != nil {
+		t.Errorf("Sign() without opts: %v", err)
+	}
+
+	// x509 certificates signed through crypto.Signer
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "signer"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		SignatureAlgorithm:    x509.SHA256WithRSAPSS,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+	if err != nil {
+		t.Fatalf("x509.CreateCertificate() error = %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	if err := cert.CheckSignatureFrom(cert); err != nil {
+		t.Errorf("CheckSignatureFrom() error = %v", err)
+	}
+
+}
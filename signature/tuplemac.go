@@ -0,0 +1,35 @@
+package signature
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+
+	"github.com/priyanshujain/crypto/hash"
+)
+
+// CalculateTupleHmac calculates the HMAC of the hash.EncodeTuple encoding of fields in hex,
+// so fields can't be shifted into each other like in a concatenation
+func CalculateTupleHmac(key []byte, algorithm string, fields ...any) (string, error) {
+	encoded, err := hash.EncodeTuple(fields...)
+	if err != nil {
+		return "", err
+	}
+	return CalculateHmac(key, encoded, algorithm)
+}
+
+// VerifyTupleHmac checks a hex HMAC of CalculateTupleHmac in constant time
+func VerifyTupleHmac(key []byte, algorithm, mac string, fields ...any) error {
+	expected, err := CalculateTupleHmac(key, algorithm, fields...)
+	if err != nil {
+		return err
+	}
+	decoded, err := hex.DecodeString(mac)
+	if err != nil {
+		return ErrInvalidMac
+	}
+	expectedBytes, _ := hex.DecodeString(expected)
+	if !hmac.Equal(decoded, expectedBytes) {
+		return ErrInvalidMac
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package signature
+
+import (
+	"testing"
+
+	"github.com/priyanshujain/crypto/hash"
+)
+
+func TestTupleHmac(t *testing.T) {
+	key := []byte("secret")
+	mac, err := CalculateTupleHmac(key, "SHA256", "POST", "/v1/orders", int64(1700000000), []byte(`{"id":1}`))
+	if err != nil {
+		t.Fatalf("CalculateTupleHmac() error = %v", err)
+	}
+	encoded, _ := hash.EncodeTuple("POST", "/v1/orders", int64(1700000000), []byte(`{"id":1}`))
+	want, _ := CalculateHmac(key, encoded, "SHA256")
+	if mac != want {
+		t.Errorf("CalculateTupleHmac() = %s, want %s", mac, want)
+	}
+	if err := VerifyTupleHmac(key, "SHA256", mac, "POST", "/v1/orders", int64(1700000000), []byte(`{"id":1}`)); err != nil {
+		t.Errorf("VerifyTupleHmac() error = %v", err)
+	}
+
+	// concatenations of the same bytes have different macs
+	ab, _ := CalculateTupleHmac(key, "SHA256", "ab", "c")
+	if err := VerifyTupleHmac(key, "SHA256", ab, "a", "bc"); err != ErrInvalidMac {
+		t.Errorf("VerifyTupleHmac() of shifted fields error = %v, want %v", err, ErrInvalidMac)
+	}
+	if err := VerifyTupleHmac(key, "SHA256", "not hex", "ab", "c"); err != ErrInvalidMac {
+		t.Errorf("VerifyTupleHmac() of a malformed mac error = %v, want %v", err, ErrInvalidMac)
+	}
+	if _, err := CalculateTupleHmac(key, "SHA256", 1.5); err != hash.ErrUnsupportedTupleType {
+		t.Errorf("CalculateTupleHmac() error = %v, want %v", err, hash.ErrUnsupportedTupleType)
+	}
+	if _, err := CalculateTupleHmac(key, "MD5", "a"); err == nil {
+		t.Errorf("CalculateTupleHmac() with MD5 succeeded")
+	}
+}